@@ -0,0 +1,132 @@
+package achievement
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+)
+
+// Kinds of events achievements are checked on
+const (
+	// a message with a prime number of letters
+	PrimeMessage = "prime"
+	// a bet was won, Count is how many the user has won
+	BetWon = "betwon"
+	// a tier role was given, Name is the tier, Tier its kind and Count its value
+	TierGained = "tiergained"
+	// a tier role was taken away, Name is the tier, Tier its kind and Count its value
+	TierLost = "tierlost"
+	// a user kept up their activity streak, Count is how many days
+	Streak = "streak"
+	// a season ended, Count is where the user finished
+	SeasonEnded = "seasonended"
+)
+
+// Event Something that happened that might unlock an achievement
+type Event struct {
+	Kind      string
+	UserID    string
+	GuildID   string
+	ChannelID string
+	Count     int
+	Name      string
+	Tier      string
+}
+
+// Achievement Something users can unlock, it's checked on every event of its kind
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Badge       string
+	Kind        string
+	Check       func(Event) bool
+}
+
+var (
+	achievements = []Achievement{
+		{ID: "prime", Name: "Prime Time", Description: "Send a message with a prime number of letters", Badge: "🔢", Kind: PrimeMessage},
+		{ID: "bets10", Name: "High Roller", Description: "Win 10 bets", Badge: "🎲", Kind: BetWon, Check: atLeast(10)},
+		{ID: "supreme", Name: "All Hail", Description: "Get the tier for the top spot", Badge: "👑", Kind: TierGained, Check: tier("rank", 1)},
+		{ID: "escaped", Name: "Escape Artist", Description: "Climb out of a bottom tier", Badge: "🏃", Kind: TierLost, Check: tier("below", -1)},
+		{ID: "streak30", Name: "Regular", Description: "Be active 30 days in a row", Badge: "📅", Kind: Streak, Check: atLeast(30)},
+		{ID: "champion", Name: "Champion", Description: "Finish a season on top", Badge: "🏆", Kind: SeasonEnded, Check: atMost(1)},
+	}
+	byID map[string]Achievement
+	mux  sync.Mutex
+)
+
+func init() {
+	byID = make(map[string]Achievement)
+	for _, v := range achievements {
+		byID[v.ID] = v
+	}
+}
+
+func atLeast(count int) func(Event) bool {
+	return func(e Event) bool { return e.Count >= count }
+}
+
+func atMost(count int) func(Event) bool {
+	return func(e Event) bool { return e.Count > 0 && e.Count <= count }
+}
+
+// tiers go by their kind and value so servers can name them anything, a value under 0 matches any
+func tier(kind string, value int) func(Event) bool {
+	return func(e Event) bool { return e.Tier == kind && (value < 0 || e.Count == value) }
+}
+
+// the achievements an event is good enough for
+func unlocks(e Event) (unlocked []Achievement) {
+	for _, v := range achievements {
+		if v.Kind == e.Kind && (v.Check == nil || v.Check(e)) {
+			unlocked = append(unlocked, v)
+		}
+	}
+	return
+}
+
+// Fire Check an event against every achievement and announce the ones the user just unlocked
+func Fire(e Event) {
+	for _, v := range unlock(e) {
+		reply := fmt.Sprintf("%v <@%v> unlocked **%v**: %v", v.Badge, e.UserID, v.Name, v.Description)
+		if e.ChannelID != "" {
+			state.SendReply(e.ChannelID, reply)
+		} else if e.GuildID != "" {
+			state.Announce(e.GuildID, reply)
+		}
+	}
+}
+
+// save the achievements the user doesn't have yet, announcing them is left until after
+func unlock(e Event) (unlocked []Achievement) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	for _, v := range unlocks(e) {
+		if db.AddAchievement(db.UserAchievement{UserID: e.UserID, Achievement: v.ID, GuildID: e.GuildID, Time: time.Now()}) {
+			logging.Log(fmt.Sprintf("%v unlocked %v", e.UserID, v.ID))
+			unlocked = append(unlocked, v)
+		}
+	}
+	return
+}
+
+// Unlocked Describe the achievements a user has, oldest first, dated in the given timezone
+func Unlocked(userID string, location *time.Location) (lines []string) {
+	for _, v := range db.GetUserAchievements(userID) {
+		if a, ok := byID[v.Achievement]; ok {
+			lines = append(lines, fmt.Sprintf("%v %v - %v (%v)", a.Badge, a.Name, a.Description, v.Time.In(location).Format("2006-01-02")))
+		}
+	}
+	return
+}
+
+// Count How many achievements there are to unlock
+func Count() int {
+	return len(achievements)
+}
@@ -0,0 +1,48 @@
+package achievement
+
+import "testing"
+
+func TestUnlocks(t *testing.T) {
+	cases := []struct {
+		event Event
+		want  []string
+	}{
+		{Event{Kind: PrimeMessage}, []string{"prime"}},
+		{Event{Kind: BetWon, Count: 9}, nil},
+		{Event{Kind: BetWon, Count: 10}, []string{"bets10"}},
+		{Event{Kind: TierGained, Name: "Supreme Ruler", Tier: "rank", Count: 1}, []string{"supreme"}},
+		{Event{Kind: TierGained, Name: "The Boss", Tier: "rank", Count: 1}, []string{"supreme"}},
+		{Event{Kind: TierGained, Name: "Supreme Ruler", Tier: "rank", Count: 3}, nil},
+		{Event{Kind: TierGained, Name: "Losers", Tier: "below", Count: 0}, nil},
+		{Event{Kind: TierLost, Name: "Losers", Tier: "below", Count: 0}, []string{"escaped"}},
+		{Event{Kind: TierLost, Name: "Peasants", Tier: "below", Count: 10}, []string{"escaped"}},
+		{Event{Kind: Streak, Count: 31}, []string{"streak30"}},
+		{Event{Kind: SeasonEnded, Count: 1}, []string{"champion"}},
+		{Event{Kind: SeasonEnded, Count: 2}, nil},
+		{Event{Kind: "nothing"}, nil},
+	}
+
+	for _, v := range cases {
+		got := unlocks(v.event)
+		if len(got) != len(v.want) {
+			t.Errorf("%+v: wanted %v, got %v", v.event, v.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i].ID != v.want[i] {
+				t.Errorf("%+v: wanted %v, got %v", v.event, v.want, got[i].ID)
+			}
+		}
+	}
+}
+
+func TestAchievementIDs(t *testing.T) {
+	if len(byID) != len(achievements) {
+		t.Errorf("Achievement IDs aren't unique")
+	}
+	for _, v := range achievements {
+		if v.ID == "" || v.Name == "" || v.Kind == "" || len(v.ID) > 50 {
+			t.Errorf("Achievement is missing something %+v", v)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jaggernaut555/respecbot/achievement"
 	"github.com/Jaggernaut555/respecbot/db"
 	"github.com/Jaggernaut555/respecbot/logging"
 	"github.com/Jaggernaut555/respecbot/rate"
+	"github.com/Jaggernaut555/respecbot/score"
 	"github.com/Jaggernaut555/respecbot/state"
 	"github.com/bwmarrin/discordgo"
 )
@@ -34,23 +36,18 @@ type Bet struct {
 	endTime      time.Time
 	channelID    string
 	guildID      string
+	location     *time.Location
 	announcement *discordgo.Message
 }
 
 var (
 	allBets  map[string]*Bet
 	betMuxes map[string]*sync.Mutex
-	location *time.Location
 )
 
 func init() {
 	allBets = make(map[string]*Bet)
 	betMuxes = make(map[string]*sync.Mutex)
-	var err error
-	location, err = time.LoadLocation("America/Vancouver")
-	if err != nil {
-		panic(err)
-	}
 }
 
 func BetCmd(message *discordgo.Message, args []string) {
@@ -136,7 +133,7 @@ func activeBetCommand(mux *sync.Mutex, b *Bet, author *discordgo.User, message *
 	// validate user can call
 	case "call":
 		if !userStatus && ok && !b.started {
-			available := db.GetUserRespec(author)
+			available := score.Get(author)
 			if available >= b.respec {
 				b.state <- betMessage{user: author, arg: "call"}
 			} else {
@@ -163,7 +160,7 @@ func activeBetCommand(mux *sync.Mutex, b *Bet, author *discordgo.User, message *
 func createBet(mux *sync.Mutex, author *discordgo.User, message *discordgo.Message, args []string) {
 	// bet does not exist, check if valid bet then create it
 	// validate user has enough respec to create bet
-	available := db.GetUserRespec(author)
+	available := score.Get(author)
 	num, err := strconv.Atoi(args[1])
 	if err != nil || num < 1 || available < num {
 		reply := fmt.Sprintf("Invalid wager")
@@ -184,7 +181,8 @@ func createBet(mux *sync.Mutex, author *discordgo.User, message *discordgo.Messa
 	b.respec = num
 	b.totalRespec = num
 	b.state = make(chan betMessage, 5)
-	b.time = time.Now().In(location)
+	b.location = rate.Location(b.guildID, "")
+	b.time = time.Now()
 	b.users = make(map[string]*discordgo.User)
 	b.userStatus = make(map[string]bool)
 
@@ -266,7 +264,7 @@ func roleHelper(guild *discordgo.Guild, roleID string, respecNeeded int) (users
 }
 
 func userCanBet(user *discordgo.User, respecNeeded int) bool {
-	if available := db.GetUserRespec(user); available >= respecNeeded || !user.Bot {
+	if available := score.Get(user); available >= respecNeeded || !user.Bot {
 		return true
 	}
 	return false
@@ -315,6 +313,7 @@ Loop:
 		logging.Log(fmt.Sprintf("Bet ended. %v won %v respec", b.users[b.winnerID].Username, b.totalRespec-b.respec))
 		winnerCard(b)
 		recordBet(b)
+		achievement.Fire(achievement.Event{Kind: achievement.BetWon, UserID: b.winnerID, GuildID: b.guildID, ChannelID: b.channelID, Count: db.CountBetsWon(b.winnerID)})
 	} else {
 		cancelBet(b)
 		deleteEmbed(b)
@@ -334,7 +333,7 @@ func callBet(b *Bet, user *discordgo.User) {
 
 	logging.Log(fmt.Sprintf("%+v called", user.String()))
 
-	rate.AddRespec(b.guildID, user, -b.respec)
+	rate.AddRespecReason(b.guildID, user, -b.respec, "bet", "")
 }
 
 func loseBet(b *Bet, user *discordgo.User) {
@@ -355,15 +354,15 @@ func dropOut(b *Bet, user *discordgo.User) {
 
 	logging.Log(fmt.Sprintf("%+v dropped out", user.String()))
 
-	rate.AddRespec(b.guildID, user, b.respec)
+	rate.AddRespecReason(b.guildID, user, b.respec, "bet", "")
 }
 
 func betWon(b *Bet) {
-	rate.AddRespec(b.guildID, b.users[b.winnerID], b.totalRespec)
+	rate.AddRespecReason(b.guildID, b.users[b.winnerID], b.totalRespec, "bet", "")
 
 	for _, v := range b.users {
 		if v.ID != b.winnerID {
-			rate.AddRespec(b.guildID, v, -b.respec)
+			rate.AddRespecReason(b.guildID, v, -b.respec, "bet", "")
 		}
 	}
 }
@@ -374,7 +373,7 @@ func cancelBet(b *Bet) {
 	}
 	for k, v := range b.userStatus {
 		if v {
-			rate.AddRespec(b.guildID, b.users[k], b.respec)
+			rate.AddRespecReason(b.guildID, b.users[k], b.respec, "bet", "")
 			b.userStatus[k] = false
 		}
 		delete(b.userStatus, k)
@@ -411,8 +410,7 @@ func startBet(b *Bet) {
 	}
 	go betEndTimer(b.state)
 	b.endTime = b.time.Add(time.Minute * 30)
-	timeStamp := fmt.Sprintf(b.endTime.Format("15:04:05"))
-	reply := fmt.Sprintf("Bet started: Total pot:%v Must end before %v.", b.totalRespec, timeStamp)
+	reply := fmt.Sprintf("Bet started: Total pot:%v Must end before %v.", b.totalRespec, rate.LocalTime(b.endTime, b.location, "15:04:05"))
 
 	logging.Log(reply)
 }
@@ -460,14 +458,14 @@ func activeBetEmbed(b *Bet) {
 
 	if b.started {
 		title = fmt.Sprintf("Bet (%v) Started", b.respec)
-		embed.Footer.Text = fmt.Sprintf("Bet ends at %v", b.endTime.Format("15:04:05"))
+		embed.Footer.Text = fmt.Sprintf("Bet ends at %v", rate.LocalTime(b.endTime, b.location, "15:04:05"))
 
 	} else {
 		title = fmt.Sprintf("Bet (%v) Not Started", b.respec)
 		if b.open {
 			title += " (ANYONE CAN JOIN)"
 		}
-		embed.Footer.Text = fmt.Sprintf("Bet starts at %v", b.time.Add(time.Minute*2).Format("15:04:05"))
+		embed.Footer.Text = fmt.Sprintf("Bet starts at %v", rate.LocalTime(b.time.Add(time.Minute*2), b.location, "15:04:05"))
 	}
 
 	embed.Title = title
@@ -509,7 +507,7 @@ func winnerCard(b *Bet) {
 	embed.URL = "https://www.youtube.com/watch?v=1EKTw50Uf8M"
 	embed.Thumbnail.URL = "https://i.imgur.com/5Gwne2N.png"
 	embed.Type = "rich"
-	embed.Footer.Text = fmt.Sprintf("Bet ended at %v", time.Now().In(location).Format("15:04:05"))
+	embed.Footer.Text = fmt.Sprintf("Bet ended at %v", rate.LocalTime(time.Now(), b.location, "15:04:05"))
 
 	for k, v := range b.users {
 		field := new(discordgo.MessageEmbedField)
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/Jaggernaut555/respecbot/bet"
+	"github.com/Jaggernaut555/respecbot/config"
 
 	"github.com/Jaggernaut555/respecbot/db"
 	"github.com/Jaggernaut555/respecbot/rate"
@@ -37,12 +38,34 @@ var CmdFuncs CmdFuncsType
 // Initializes the cmds map
 func init() {
 	CmdFuncs = CmdFuncsType{
-		"help":     CmdFuncHelpType{cmdHelp, "Prints this list", false},
-		"lookatme": CmdFuncHelpType{cmdHere, "Fuck off, user", false},
-		"fuckoff":  CmdFuncHelpType{cmdNotHere, "Fuck off, bot", true},
-		"version":  CmdFuncHelpType{cmdVersion, "Outputs the current bot version", true},
-		"stats":    CmdFuncHelpType{cmdStats, "Displays stats about this bot", true},
-		"bet":      CmdFuncHelpType{cmdBet, "WHO GONNA WIN? `bet help`", true},
+		"help":       CmdFuncHelpType{cmdHelp, "Prints this list", false},
+		"lookatme":   CmdFuncHelpType{cmdHere, "Fuck off, user", false},
+		"fuckoff":    CmdFuncHelpType{cmdNotHere, "Fuck off, bot", true},
+		"version":    CmdFuncHelpType{cmdVersion, "Outputs the current bot version", true},
+		"stats":      CmdFuncHelpType{cmdStats, "Displays stats about this bot `stats help`", true},
+		"bet":        CmdFuncHelpType{cmdBet, "WHO GONNA WIN? `bet help`", true},
+		"lexicon":    CmdFuncHelpType{cmdLexicon, "Words that make me happy or sad `lexicon help`", true},
+		"config":     CmdFuncHelpType{cmdConfig, "Show or change this server's settings", true},
+		"domain":     CmdFuncHelpType{cmdDomain, "Links I like and links I don't `domain help`", true},
+		"emoji":      CmdFuncHelpType{cmdEmoji, "How much each reaction is worth `emoji help`", true},
+		"rings":      CmdFuncHelpType{cmdCollusion, "Who's been scratching each other's backs (moderators)", true},
+		"history":    CmdFuncHelpType{cmdHistory, "Where did my respec go? `history [@user]`", true},
+		"flips":      CmdFuncHelpType{cmdFlips, "When the bot said no `flips [@user]`", true},
+		"fairness":   CmdFuncHelpType{cmdFairness, "Check the bot isn't cheating", true},
+		"tier":       CmdFuncHelpType{cmdTier, "Who rules and who loses `tier help`", true},
+		"queue":      CmdFuncHelpType{cmdQueue, "How far behind the bot is", true},
+		"season":     CmdFuncHelpType{cmdSeason, "How long until it's all over `season help`", true},
+		"halloffame": CmdFuncHelpType{cmdHallOfFame, "The champions of seasons past", true},
+		"profile":    CmdFuncHelpType{cmdProfile, "Everything about you `profile [@user]`", true},
+		"streak":     CmdFuncHelpType{cmdStreak, "How many days in a row `streak [@user]`", true},
+		"give":       CmdFuncHelpType{cmdGive, "Share the wealth `give @user [amount] [note]`", true},
+		"tip":        CmdFuncHelpType{cmdTip, "A little something `tip @user [note]`", true},
+		"shop":       CmdFuncHelpType{cmdShop, "Spend that respec `shop help`", true},
+		"buy":        CmdFuncHelpType{cmdBuy, "Buy something from the shop `buy [id]`", true},
+		"inventory":  CmdFuncHelpType{cmdInventory, "What you've bought `inventory [@user]`", true},
+		"channel":    CmdFuncHelpType{cmdChannel, "How this channel is rated `channel help`", true},
+		"appeal":     CmdFuncHelpType{cmdAppeal, "That wasn't fair `appeal help`", true},
+		"timezone":   CmdFuncHelpType{cmdTimezone, "Where you are `timezone [Area/City|reset|server [Area/City]]`", true},
 	}
 }
 
@@ -92,7 +115,7 @@ func cmdHere(message *discordgo.MessageCreate, args []string) {
 		panic(err)
 	}
 
-	if state.Channels[channel.ID] {
+	if state.IsValidChannel(channel.ID) {
 		state.SendReply(channel.ID, "Yeah")
 		return
 	}
@@ -102,23 +125,111 @@ func cmdHere(message *discordgo.MessageCreate, args []string) {
 
 func cmdNotHere(message *discordgo.MessageCreate, args []string) {
 	channel, _ := state.Session.Channel(message.ChannelID)
-	state.Channels[channel.ID] = false
-	state.Servers[channel.GuildID] = false
+	state.SetChannel(channel, false)
 	db.AddChannel(channel, false)
 
 }
 
 func cmdStats(message *discordgo.MessageCreate, args []string) {
-	leaders, losers := rate.GetRespec()
-	var stats = "Leaderboard:\n```\n"
-	stats += leaders
-	stats += "```"
-	stats += "\nLosers:` "
-	stats += strings.Join(losers, ", ")
-	stats += " `"
-	state.SendReply(message.ChannelID, stats)
+	rate.StatsCmd(message.Message, args)
 }
 
 func cmdBet(message *discordgo.MessageCreate, args []string) {
 	bet.BetCmd(message.Message, args)
 }
+
+func cmdLexicon(message *discordgo.MessageCreate, args []string) {
+	rate.LexiconCmd(message.Message, args)
+}
+
+func cmdConfig(message *discordgo.MessageCreate, args []string) {
+	config.ConfigCmd(message.Message, args)
+}
+
+func cmdDomain(message *discordgo.MessageCreate, args []string) {
+	rate.DomainCmd(message.Message, args)
+}
+
+func cmdEmoji(message *discordgo.MessageCreate, args []string) {
+	rate.EmojiCmd(message.Message, args)
+}
+
+func cmdCollusion(message *discordgo.MessageCreate, args []string) {
+	rate.CollusionCmd(message.Message, args)
+}
+
+func cmdHistory(message *discordgo.MessageCreate, args []string) {
+	rate.HistoryCmd(message.Message, args)
+}
+
+func cmdFlips(message *discordgo.MessageCreate, args []string) {
+	rate.FlipsCmd(message.Message, args)
+}
+
+func cmdFairness(message *discordgo.MessageCreate, args []string) {
+	rate.FairnessCmd(message.Message, args)
+}
+
+func cmdTier(message *discordgo.MessageCreate, args []string) {
+	rate.TierCmd(message.Message, args)
+}
+
+func cmdSeason(message *discordgo.MessageCreate, args []string) {
+	rate.SeasonCmd(message.Message, args)
+}
+
+func cmdHallOfFame(message *discordgo.MessageCreate, args []string) {
+	rate.HallOfFameCmd(message.Message, args)
+}
+
+func cmdProfile(message *discordgo.MessageCreate, args []string) {
+	rate.ProfileCmd(message.Message, args)
+}
+
+func cmdStreak(message *discordgo.MessageCreate, args []string) {
+	rate.StreakCmd(message.Message, args)
+}
+
+func cmdGive(message *discordgo.MessageCreate, args []string) {
+	rate.GiveCmd(message.Message, args)
+}
+
+func cmdTip(message *discordgo.MessageCreate, args []string) {
+	rate.TipCmd(message.Message, args)
+}
+
+func cmdShop(message *discordgo.MessageCreate, args []string) {
+	rate.ShopCmd(message.Message, args)
+}
+
+func cmdBuy(message *discordgo.MessageCreate, args []string) {
+	rate.BuyCmd(message.Message, args)
+}
+
+func cmdInventory(message *discordgo.MessageCreate, args []string) {
+	rate.InventoryCmd(message.Message, args)
+}
+
+func cmdTimezone(message *discordgo.MessageCreate, args []string) {
+	rate.TimezoneCmd(message.Message, args)
+}
+
+func cmdChannel(message *discordgo.MessageCreate, args []string) {
+	rate.ChannelCmd(message.Message, args)
+}
+
+func cmdAppeal(message *discordgo.MessageCreate, args []string) {
+	rate.AppealCmd(message.Message, args)
+}
+
+func cmdQueue(message *discordgo.MessageCreate, args []string) {
+	stats := pipeline.Stats()
+	reply := "```\n"
+	reply += fmt.Sprintf("Waiting:   %v/%v (most %v)\n", stats.Depth, stats.Capacity, stats.MaxDepth)
+	reply += fmt.Sprintf("Workers:   %v\n", stats.Workers)
+	reply += fmt.Sprintf("Rated:     %v\n", stats.Processed)
+	reply += fmt.Sprintf("Dropped:   %v\n", stats.Dropped)
+	reply += fmt.Sprintf("Failed:    %v\n", stats.Failed)
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
@@ -9,6 +9,7 @@ import (
 	"syscall"
 
 	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/queue"
 	"github.com/Jaggernaut555/respecbot/state"
 
 	"github.com/Jaggernaut555/respecbot/logging"
@@ -22,15 +23,37 @@ import (
 var (
 	discordToken string
 	dbPassword   string
+	pipeline     *queue.Pipeline
 )
 
+var overloadPolicies = map[string]queue.Overload{
+	"block":      queue.Block,
+	"drop":       queue.DropNewest,
+	"dropoldest": queue.DropOldest,
+}
+
 func initBot() {
 	flag.StringVar(&discordToken, "t", "", "Discord Authentication token")
 	flag.StringVar(&dbPassword, "p", "", "Password for database user")
 	purge := flag.Bool("purge", false, "Use this flag to purge the database. Must be used with -p")
+	workers := flag.Int("workers", 4, "Number of workers rating messages")
+	queueSize := flag.Int("queue", 100, "Number of messages each worker can have waiting")
+	overload := flag.String("overload", "block", "What to do with messages when the queue is full: block, drop or dropoldest. Reactions always wait")
 
 	flag.Parse()
 
+	policy, ok := overloadPolicies[*overload]
+	if !ok {
+		panic(fmt.Sprintf("Unknown overload policy %v", *overload))
+	}
+	var err error
+	pipeline, err = queue.NewPipeline(*workers, *queueSize, policy, func(r interface{}) {
+		logging.Log(fmt.Sprintf("rating failed: %v", r))
+	})
+	if err != nil {
+		panic(err)
+	}
+
 	db.DBSetup(dbPassword, *purge)
 	state.InitChannels()
 	rate.InitRatings()
@@ -52,6 +75,9 @@ func LaunchBot() {
 		return
 	}
 
+	// handlers run one at a time in the order discord sent the events so the
+	// pipeline sees them in order, a full queue holds up the gateway instead of piling up goroutines
+	state.Session.SyncEvents = true
 	// add a handler for when messages are posted
 	state.Session.AddHandler(messageCreate)
 	state.Session.AddHandler(reactionAdd)
@@ -70,20 +96,20 @@ func LaunchBot() {
 	<-sc
 
 	defer state.Session.Close()
+	// finish rating what's already come in
+	pipeline.Close()
 }
 
 func announceReturn() {
-	for k, v := range state.Channels {
-		if v {
-			channel, err := state.Session.Channel(k)
-			if err != nil {
-				panic(err)
-			}
-			if active, ok := state.Servers[channel.GuildID]; active && ok {
-				reply := fmt.Sprintf("I'm back, bitches, and I'm running %v", Version)
-				state.SendReply(k, reply)
-				rate.InitChannel(channel.ID)
-			}
+	for _, k := range state.ActiveChannels() {
+		channel, err := state.Session.Channel(k)
+		if err != nil {
+			panic(err)
+		}
+		if state.IsActiveServer(channel.GuildID) {
+			reply := fmt.Sprintf("I'm back, bitches, and I'm running %v", Version)
+			state.SendReply(k, reply)
+			rate.InitChannel(channel.ID)
 		}
 	}
 }
@@ -94,24 +120,45 @@ func messageCreate(session *discordgo.Session, message *discordgo.MessageCreate)
 		return
 	}
 
+	// commands can wait on bets and replies, they don't get to hold up the gateway
 	if strings.HasPrefix(message.Content, CmdChar) {
-		HandleCommand(message, strings.TrimPrefix(message.Content, CmdChar))
+		go HandleCommand(message, strings.TrimPrefix(message.Content, CmdChar))
 		return
 	}
 
 	// rate users on everything else they get
-	channel, err := session.Channel(message.ChannelID)
-	if err != nil {
-		return
-	} else if channel != nil && state.Servers[channel.GuildID] == true && state.Channels[channel.ID] == true {
-		rate.RespecMessage(message.Message)
-	}
+	enqueue(message.Author.ID, func() {
+		channel, err := session.Channel(message.ChannelID)
+		if err != nil {
+			return
+		} else if channel != nil && state.IsActiveServer(channel.GuildID) && state.IsValidChannel(channel.ID) {
+			rate.RespecMessage(message.Message)
+		}
+	})
 }
 
 func reactionAdd(session *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
-	rate.RespecReaction(reaction.MessageReaction, true)
+	enqueueWait(reaction.UserID, func() {
+		rate.RespecReaction(reaction.MessageReaction, true)
+	})
 }
 
 func reactionRemove(session *discordgo.Session, reaction *discordgo.MessageReactionRemove) {
-	rate.RespecReaction(reaction.MessageReaction, false)
+	enqueueWait(reaction.UserID, func() {
+		rate.RespecReaction(reaction.MessageReaction, false)
+	})
+}
+
+// rate things off the gateway, everything one user does is rated in the order it came in
+func enqueue(userID string, job func()) {
+	if err := pipeline.Push(userID, job); err != nil {
+		logging.Log(fmt.Sprintf("not rating message from %v: %v", userID, err))
+	}
+}
+
+// reactions are never dropped, a removed reaction has to take back what adding it gave
+func enqueueWait(userID string, job func()) {
+	if err := pipeline.PushWait(userID, job); err != nil {
+		logging.Log(fmt.Sprintf("not rating reaction from %v: %v", userID, err))
+	}
 }
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Setting A setting guilds can change and its default value
+type Setting struct {
+	Default string
+	Help    string
+	isInt   bool
+	choices []string
+	check   func(string) error
+}
+
+var (
+	settings    map[string]Setting
+	guildValues map[string]map[string]string
+	mux         sync.Mutex
+)
+
+func init() {
+	settings = make(map[string]Setting)
+	guildValues = make(map[string]map[string]string)
+}
+
+// Register Add a setting guilds are able to change, settings with a number default only take numbers
+func Register(name, defaultValue, help string) {
+	_, err := strconv.Atoi(defaultValue)
+	settings[name] = Setting{Default: defaultValue, Help: help, isInt: err == nil}
+}
+
+// RegisterChoice Add a setting guilds are able to change to one of the given choices
+func RegisterChoice(name, defaultValue, help string, choices ...string) {
+	settings[name] = Setting{Default: defaultValue, Help: help, choices: choices}
+}
+
+// RegisterCheck Add a setting guilds are able to change to anything check allows
+func RegisterCheck(name, defaultValue, help string, check func(string) error) {
+	settings[name] = Setting{Default: defaultValue, Help: help, check: check}
+}
+
+// String Get the guild's value for a setting
+func String(guildID, name string) string {
+	mux.Lock()
+	defer mux.Unlock()
+
+	values, ok := guildValues[guildID]
+	if !ok {
+		values = make(map[string]string)
+		db.LoadGuildSettings(guildID, &values)
+		guildValues[guildID] = values
+	}
+
+	if value, ok := values[name]; ok {
+		return value
+	}
+	return settings[name].Default
+}
+
+// Int Get the guild's value for a number setting
+func Int(guildID, name string) int {
+	value, err := strconv.Atoi(String(guildID, name))
+	if err != nil {
+		value, _ = strconv.Atoi(settings[name].Default)
+	}
+	return value
+}
+
+// Set Change the guild's value for a setting
+func Set(guildID, name, value string) error {
+	setting, ok := settings[name]
+	if !ok {
+		return fmt.Errorf("No setting named %v", name)
+	}
+	if _, err := strconv.Atoi(value); setting.isInt && err != nil {
+		return fmt.Errorf("%v must be a number", name)
+	}
+	if len(setting.choices) > 0 {
+		valid := false
+		for _, v := range setting.choices {
+			valid = valid || v == value
+		}
+		if !valid {
+			return fmt.Errorf("%v must be one of %v", name, strings.Join(setting.choices, ", "))
+		}
+	}
+	if setting.check != nil {
+		if err := setting.check(value); err != nil {
+			return err
+		}
+	}
+
+	db.SetGuildSetting(guildID, name, value)
+	forget(guildID)
+	return nil
+}
+
+// Reset Put a guild's setting back to the default
+func Reset(guildID, name string) error {
+	if _, ok := settings[name]; !ok {
+		return fmt.Errorf("No setting named %v", name)
+	}
+
+	db.RemoveGuildSetting(guildID, name)
+	forget(guildID)
+	return nil
+}
+
+func forget(guildID string) {
+	mux.Lock()
+	delete(guildValues, guildID)
+	mux.Unlock()
+}
+
+// ConfigCmd Handle the config command to view or change the guild's settings
+func ConfigCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	if len(args) < 2 {
+		var keys []string
+		for k := range settings {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		reply := "Use `config [setting] [value]` to change a setting or `config [setting] reset`\n```\n"
+		for _, k := range keys {
+			reply += fmt.Sprintf("%v = %v (%v)\n", k, String(guildID, k), settings[k].Help)
+		}
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	name := strings.ToLower(args[1])
+	setting, ok := settings[name]
+	if !ok {
+		state.SendReply(message.ChannelID, fmt.Sprintf("I do not have setting `%v`", name))
+		return
+	}
+
+	if len(args) < 3 {
+		reply := fmt.Sprintf("`%v` = `%v` (default `%v`) %v", name, String(guildID, name), setting.Default, setting.Help)
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+		state.SendReply(message.ChannelID, "You can't do that")
+		return
+	}
+
+	if strings.ToLower(args[2]) == "reset" {
+		err = Reset(guildID, name)
+	} else {
+		err = Set(guildID, name, strings.Join(args[2:], " "))
+	}
+	if err != nil {
+		state.SendReply(message.ChannelID, err.Error())
+		return
+	}
+	state.SendReply(message.ChannelID, fmt.Sprintf("`%v` is now `%v`", name, String(guildID, name)))
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"sort"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -26,16 +26,19 @@ type Message struct {
 	UserID    string    `xorm:"not null"`
 	Respec    int       `xorm:"default 0"`
 	Time      time.Time `xorm:"not null"`
+	ReplyToID string    `xorm:"varchar(50) index"`
 }
 
 type Channel struct {
 	ID      string `xorm:"varchar(50) pk"`
 	GuildID string `xorm:"not null"`
 	Active  bool   `xorm:"default 0"`
+	// the rule profile messages here are rated with, empty for the default
+	Profile string `xorm:"varchar(20)"`
 }
 
 type Reaction struct {
-	Content   string    `xorm:"varchar(50) pk"`
+	Content   string    `xorm:"varchar(100) pk"`
 	MessageID string    `xorm:"varchar(50) pk"`
 	UserID    string    `xorm:"varchar(50) pk"`
 	Time      time.Time `xorm:"not null"`
@@ -46,8 +49,11 @@ type Mention struct {
 	GiverID    string    `xorm:"varchar(50) pk"`
 	ReceiverID string    `xorm:"varchar(50) pk"`
 	MessageID  string    `xorm:"varchar(50) pk"`
+	GuildID    string    `xorm:"varchar(50) index"`
 	Time       time.Time `xorm:"not null"`
 	Respec     int       `xorm:"default 0"`
+	// which limit stopped the mention giving respec, empty if none did
+	LimitApplied string `xorm:"varchar(20)"`
 }
 
 type DBBet struct {
@@ -64,12 +70,219 @@ func (*DBBet) TableName() string {
 	return "Bet"
 }
 
+// Every change to a user's respec and why it happened
+type History struct {
+	ID      uint64    `xorm:"pk autoincr"`
+	UserID  string    `xorm:"varchar(50) not null index"`
+	GuildID string    `xorm:"varchar(50) index(GuildTime)"`
+	Respec  int       `xorm:"default 0"`
+	Reason  string    `xorm:"varchar(50)"`
+	Ref     string    `xorm:"varchar(50) index"`
+	Time    time.Time `xorm:"not null index index(GuildTime)"`
+}
+
+// A change that got flipped and everything that went into it
+type Flip struct {
+	ID          uint64    `xorm:"pk autoincr"`
+	UserID      string    `xorm:"varchar(50) not null index"`
+	GuildID     string    `xorm:"varchar(50)"`
+	Rating      int       `xorm:"default 0"`
+	Chance      float64   `xorm:"default 0"`
+	Roll        float64   `xorm:"default 0"`
+	UserRespec  int       `xorm:"default 0"`
+	TotalRespec int       `xorm:"default 0"`
+	SeedID      uint64    `xorm:"default 0"`
+	Nonce       uint64    `xorm:"default 0"`
+	Reason      string    `xorm:"varchar(50)"`
+	Ref         string    `xorm:"varchar(50)"`
+	Time        time.Time `xorm:"not null"`
+}
+
+// The secret flips are rolled from, only the hash is shown until it's revealed
+type FlipSeed struct {
+	ID         uint64    `xorm:"pk autoincr"`
+	Commitment string    `xorm:"varchar(64) not null"`
+	Seed       string    `xorm:"varchar(64) not null"`
+	Revealed   bool      `xorm:"default 0"`
+	Start      time.Time `xorm:"not null"`
+	End        time.Time `xorm:"default null"`
+}
+
+// A rank role in a guild and the rule for who gets it
+type Tier struct {
+	ID      uint64 `xorm:"pk autoincr"`
+	GuildID string `xorm:"varchar(50) not null index"`
+	Name    string `xorm:"varchar(100) not null"`
+	Kind    string `xorm:"varchar(20) not null"`
+	Value   int    `xorm:"default 0"`
+	Color   int    `xorm:"default 0"`
+	Hoist   bool   `xorm:"default 0"`
+}
+
+// A guild's season, the end is worked out from the guild's season length until it's over
+type Season struct {
+	ID      uint64    `xorm:"pk autoincr"`
+	GuildID string    `xorm:"varchar(50) not null index"`
+	Number  int       `xorm:"not null"`
+	Start   time.Time `xorm:"not null"`
+	End     time.Time `xorm:"default null"`
+	Ended   bool      `xorm:"default 0"`
+}
+
+// Where a user finished when a season ended
+type SeasonStanding struct {
+	SeasonID uint64 `xorm:"pk"`
+	UserID   string `xorm:"varchar(50) pk"`
+	Username string `xorm:"varchar(50) not null"`
+	Rank     int    `xorm:"not null"`
+	Respec   int    `xorm:"default 0"`
+}
+
+// An achievement a user has unlocked and when
+type UserAchievement struct {
+	UserID      string    `xorm:"varchar(50) pk"`
+	Achievement string    `xorm:"varchar(50) pk"`
+	GuildID     string    `xorm:"varchar(50)"`
+	Time        time.Time `xorm:"not null"`
+}
+
+// How many days in a row a user has been active in a guild, days are in the user's timezone
+type Streak struct {
+	UserID  string `xorm:"varchar(50) pk"`
+	GuildID string `xorm:"varchar(50) pk"`
+	Current int    `xorm:"default 0"`
+	Best    int    `xorm:"default 0"`
+	LastDay string `xorm:"varchar(10)"`
+	Freezes int    `xorm:"default 0"`
+	Warned  string `xorm:"varchar(10)"`
+}
+
+// Respec one user gave another
+type Transfer struct {
+	ID      uint64    `xorm:"pk autoincr"`
+	GuildID string    `xorm:"varchar(50)"`
+	FromID  string    `xorm:"varchar(50) not null index"`
+	ToID    string    `xorm:"varchar(50) not null index"`
+	Amount  int       `xorm:"not null"`
+	Note    string    `xorm:"varchar(200)"`
+	Time    time.Time `xorm:"not null index"`
+}
+
+// Something a guild sells for respec, Stock below 0 never runs out and Hours of 0 lasts forever
+type ShopItem struct {
+	ID      uint64 `xorm:"pk autoincr"`
+	GuildID string `xorm:"varchar(50) not null index"`
+	Name    string `xorm:"varchar(100) not null"`
+	Kind    string `xorm:"varchar(20) not null"`
+	Price   int    `xorm:"not null"`
+	Stock   int    `xorm:"default -1"`
+	Hours   int    `xorm:"default 0"`
+}
+
+// An item a user bought, what it changed and when it runs out
+type Purchase struct {
+	ID       uint64    `xorm:"pk autoincr"`
+	GuildID  string    `xorm:"varchar(50) not null"`
+	UserID   string    `xorm:"varchar(50) not null index"`
+	ItemID   uint64    `xorm:"not null"`
+	Name     string    `xorm:"varchar(100) not null"`
+	Kind     string    `xorm:"varchar(20) not null"`
+	Value    string    `xorm:"varchar(200)"`
+	OldValue string    `xorm:"varchar(200)"`
+	Price    int       `xorm:"not null"`
+	Time     time.Time `xorm:"not null"`
+	Expires  time.Time `xorm:"default null"`
+	Expired  bool      `xorm:"default 0 index"`
+}
+
+// The timezone a user picked, it beats the guild's
+type UserTimezone struct {
+	UserID   string `xorm:"varchar(50) pk"`
+	Timezone string `xorm:"varchar(64) not null"`
+}
+
+// A user asking everyone to undo what a message cost them
+type Appeal struct {
+	ID        uint64    `xorm:"pk autoincr"`
+	GuildID   string    `xorm:"varchar(50) not null"`
+	ChannelID string    `xorm:"varchar(50) not null"`
+	UserID    string    `xorm:"varchar(50) not null index"`
+	MessageID string    `xorm:"varchar(50) not null index"`
+	Respec    int       `xorm:"default 0"`
+	Status    string    `xorm:"varchar(10) index"`
+	Yes       int       `xorm:"default 0"`
+	No        int       `xorm:"default 0"`
+	Opened    time.Time `xorm:"not null"`
+	Closes    time.Time `xorm:"not null"`
+}
+
+// One user's vote on an appeal
+type AppealVote struct {
+	AppealID uint64 `xorm:"pk"`
+	UserID   string `xorm:"varchar(50) pk"`
+	InFavour bool   `xorm:"default 0"`
+}
+
+// A ++ or -- one user gave another in a message
+type Vote struct {
+	ID         uint64    `xorm:"pk autoincr"`
+	GuildID    string    `xorm:"varchar(50) not null"`
+	GiverID    string    `xorm:"varchar(50) not null index"`
+	ReceiverID string    `xorm:"varchar(50) not null index"`
+	MessageID  string    `xorm:"varchar(50) not null"`
+	Respec     int       `xorm:"default 0"`
+	Reason     string    `xorm:"varchar(200)"`
+	Time       time.Time `xorm:"not null index"`
+}
+
+// Per-guild additions to the sentiment lexicon
+type LexiconWord struct {
+	GuildID string `xorm:"varchar(50) pk"`
+	Word    string `xorm:"varchar(50) pk"`
+	Score   int    `xorm:"default 0"`
+}
+
+// Per-guild overrides of the default settings
+type GuildSetting struct {
+	GuildID string `xorm:"varchar(50) pk"`
+	Name    string `xorm:"varchar(50) pk"`
+	Value   string `xorm:"varchar(100) not null"`
+}
+
+// Link domains a guild allows or denies
+type GuildDomain struct {
+	GuildID string `xorm:"varchar(50) pk"`
+	Domain  string `xorm:"varchar(100) pk"`
+	Allowed bool   `xorm:"default 0"`
+}
+
+// How much respec a reaction with an emoji is worth in a guild
+type EmojiWeight struct {
+	GuildID string `xorm:"varchar(50) pk"`
+	Emoji   string `xorm:"varchar(100) pk"`
+	Weight  int    `xorm:"default 0"`
+}
+
 // ID = Bet.ID, table to hold all users who participated in a bet
 type BetUsers struct {
 	BetID  uint64 `xorm:"pk"`
 	UserID string `xorm:"varchar(50) pk"`
 }
 
+// How many times one user rewarded another in a guild
+type Interaction struct {
+	GuildID    string
+	GiverID    string
+	ReceiverID string
+	Count      int
+}
+
+// How much respec a user gained over some time
+type Standing struct {
+	UserID string
+	Respec int
+}
+
 type joinReactionMessage struct {
 	Reaction `xorm:"extends"`
 	Message  `xorm:"extends"`
@@ -136,110 +349,147 @@ func createTables(e *xorm.Engine) {
 	if err = e.Sync2(new(BetUsers)); err != nil {
 		panic(err)
 	}
+	if err = e.Sync2(new(LexiconWord)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(GuildSetting)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(GuildDomain)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(EmojiWeight)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(History)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Flip)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(FlipSeed)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Tier)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Season)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(SeasonStanding)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(UserAchievement)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Streak)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Transfer)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(ShopItem)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Purchase)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(UserTimezone)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Appeal)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(AppealVote)); err != nil {
+		panic(err)
+	}
+	if err = e.Sync2(new(Vote)); err != nil {
+		panic(err)
+	}
 }
 
-func GetTotalRespec() (total int) {
-	var user User
-
-	temp, err := engine.SumInt(user, "Respec")
-	if err != nil {
+func AddUser(userID, username string, respec int) {
+	if _, err := engine.Insert(&User{ID: userID, Username: username, Respec: respec}); err != nil {
 		panic(err)
 	}
-
-	return int(temp)
 }
 
-func GetUserRespec(discordUser *discordgo.User) (respec int) {
-	user := &User{Username: discordUser.String(), ID: discordUser.ID}
-	has, err := engine.Get(user)
-	if err != nil {
+func GainRespecByID(userID string, respec int) {
+	if _, err := engine.Table("User").Where("ID = ?", userID).Incr("Respec", respec).Update(&User{}); err != nil {
 		panic(err)
 	}
-	if has {
-		respec = user.Respec
+}
+
+func GetUsers() (users []User) {
+	if err := engine.Find(&users); err != nil {
+		panic(err)
 	}
 	return
 }
 
-func GetTopUser() (userID string) {
-	user := new(User)
-	has, err := engine.Table("User").Select("*").Desc("Respec").Get(user)
-	if err != nil {
+func AddHistory(history History) {
+	if _, err := engine.Insert(&history); err != nil {
 		panic(err)
 	}
-	if has {
-		return user.ID
-	}
-	return ""
 }
 
-func UserIsTop(discordUser *discordgo.User) bool {
-	user := new(User)
-	has, err := engine.Table("User").Select("*").Desc("Respec").Get(user)
-	if err != nil {
+func GetUserHistory(userID string, limit int) (history []History) {
+	if err := engine.Where("UserID = ?", userID).Desc("Time").Limit(limit).Find(&history); err != nil {
 		panic(err)
 	}
-	if has && user.ID == discordUser.ID {
-		return true
-	}
-	return false
+	return
 }
 
-func GetRulingClass(list *map[string]bool) {
-	var users []User
-	if err := engine.Find(&users); err != nil {
-		panic(err)
+// everyone's respec gained since the given time, most first, an empty guildID counts every guild
+func GetRespecSince(guildID string, since time.Time) (standings []Standing) {
+	session := engine.Table("History").Select("UserID, SUM(Respec) AS Respec").Where("Time >= ?", since)
+	if guildID != "" {
+		session = session.And("GuildID = ?", guildID)
 	}
-	total := float64(GetTotalRespec())
-	var pairs pairList
-	for _, v := range users {
-		pairs = append(pairs, pair{Key: v.ID, Value: v.Respec})
+	if err := session.GroupBy("UserID").Desc("Respec").Find(&standings); err != nil {
+		panic(err)
 	}
-	sort.Sort(sort.Reverse(pairs))
-	var totalPercent float64
-	for _, v := range pairs {
-		if totalPercent > 50 {
-			(*list)[v.Key] = false
-			continue
-		}
-		percent := (float64(v.Value) / total) * 100
-		totalPercent += percent
-		(*list)[v.Key] = true
+	return
+}
 
+func AddFlip(flip Flip) {
+	if _, err := engine.Insert(&flip); err != nil {
+		panic(err)
 	}
 }
 
-func LoadRespec(list *map[string]int) {
-	var users []User
-	if err := engine.Find(&users); err != nil {
+func GetUserFlips(userID string, limit int) (flips []Flip) {
+	if err := engine.Where("UserID = ?", userID).Desc("Time").Limit(limit).Find(&flips); err != nil {
 		panic(err)
 	}
-	for _, v := range users {
-		(*list)[v.Username] = v.Respec
+	return
+}
+
+func AddFlipSeed(seed *FlipSeed) {
+	if _, err := engine.Insert(seed); err != nil {
+		panic(err)
 	}
 }
 
-func GainRespec(discordUser *discordgo.User, respec int) {
-	user := &User{Username: discordUser.String(), ID: discordUser.ID}
-	has, err := engine.Get(user)
-	if err != nil {
+// RevealFlipSeeds Reveal every seed that is still secret
+func RevealFlipSeeds(timeStamp time.Time) {
+	if _, err := engine.Where("Revealed = ?", false).Cols("Revealed", "End").Update(&FlipSeed{Revealed: true, End: timeStamp}); err != nil {
 		panic(err)
 	}
-	if has {
-		user.Respec += respec
-		if _, err = engine.ID(core.PK{user.ID}).Cols("Respec").Update(user); err != nil {
-			panic(err)
-		}
-	} else {
-		user.Respec = respec
-		if _, err = engine.Insert(user); err != nil {
-			panic(err)
-		}
+}
+
+func GetFlipSeeds(limit int) (seeds []FlipSeed) {
+	if err := engine.Desc("ID").Limit(limit).Find(&seeds); err != nil {
+		panic(err)
 	}
+	return
 }
 
 func NewMessage(discordUser *discordgo.User, message *discordgo.Message, numRespec int, timeStamp time.Time) {
 	msg := &Message{ID: message.ID, Content: message.Content, ChannelID: message.ChannelID, Respec: numRespec, UserID: discordUser.String(), Time: timeStamp}
+	if message.MessageReference != nil {
+		msg.ReplyToID = message.MessageReference.MessageID
+	}
 	if _, err := engine.Insert(msg); err != nil {
 		panic(err)
 	}
@@ -253,6 +503,20 @@ func MessageExists(messageID string) (has bool) {
 	return
 }
 
+// the guild a rated message was sent in
+func GetMessageGuild(messageID string) (guildID string, ok bool) {
+	message := Message{ID: messageID}
+	has, err := engine.Get(&message)
+	if err != nil {
+		panic(err)
+	}
+	if !has {
+		return "", false
+	}
+	channel, ok := GetChannel(message.ChannelID)
+	return channel.GuildID, ok
+}
+
 func AddChannel(discordChannel *discordgo.Channel, active bool) {
 	channel := &Channel{ID: discordChannel.ID, GuildID: discordChannel.GuildID}
 	has, err := engine.Get(channel)
@@ -284,16 +548,41 @@ func GetUserLastMessageTime(userID string) (timeStamp time.Time, ok bool) {
 	return
 }
 
-func AddMention(giver *discordgo.User, receiver *discordgo.User, message *discordgo.Message, numRespec int, timeStamp time.Time) {
-	mention := Mention{GiverID: giver.String(), ReceiverID: receiver.String(), MessageID: message.ID, Respec: numRespec, Time: timeStamp}
+func GetUserLastMessage(userID string) (message Message, ok bool) {
+	has, err := engine.Where("UserID = ?", userID).Desc("Time").Get(&message)
+	if err != nil {
+		panic(err)
+	}
+	return message, has
+}
+
+// SetChannelProfile Rate a channel's messages with a different rule profile
+func SetChannelProfile(channelID, profile string) {
+	if _, err := engine.Id(core.PK{channelID}).Cols("Profile").Update(&Channel{Profile: profile}); err != nil {
+		panic(err)
+	}
+}
+
+func GetChannel(channelID string) (channel Channel, ok bool) {
+	channel.ID = channelID
+	has, err := engine.Get(&channel)
+	if err != nil {
+		panic(err)
+	}
+	return channel, has
+}
+
+func AddMention(guildID string, giver *discordgo.User, receiver *discordgo.User, message *discordgo.Message, numRespec int, limit string, timeStamp time.Time) {
+	mention := Mention{GiverID: giver.String(), ReceiverID: receiver.String(), MessageID: message.ID, GuildID: guildID, Respec: numRespec, LimitApplied: limit, Time: timeStamp}
 	if _, err := engine.Insert(mention); err != nil {
 		panic(err)
 	}
 }
 
-func GetUserLastMentionedTime(userID string) (timeStamp time.Time, ok bool) {
-	mention := Mention{ReceiverID: userID}
-	has, err := engine.Select("ReceiverID, max(Time) AS Time").GroupBy("ReceiverID").Get(&mention)
+// GetLastMentionTime When the giver last gave the receiver respec by mentioning them in a guild
+func GetLastMentionTime(guildID, giverID, receiverID string) (timeStamp time.Time, ok bool) {
+	var mention Mention
+	has, err := engine.Where("GuildID = ? AND GiverID = ? AND ReceiverID = ? AND Respec > 0", guildID, giverID, receiverID).Desc("Time").Get(&mention)
 	if err != nil {
 		panic(err)
 	}
@@ -304,8 +593,25 @@ func GetUserLastMentionedTime(userID string) (timeStamp time.Time, ok bool) {
 	return
 }
 
+// CountMentionsSince How many mentions gave the receiver respec in a guild since a time
+func CountMentionsSince(guildID, receiverID string, since time.Time) int {
+	count, err := engine.Where("GuildID = ? AND ReceiverID = ? AND Respec > 0 AND Time >= ?", guildID, receiverID, since).Count(new(Mention))
+	if err != nil {
+		panic(err)
+	}
+	return int(count)
+}
+
+// EmojiKey The name and ID of custom emoji or just the name of unicode emoji
+func EmojiKey(emoji discordgo.Emoji) string {
+	if emoji.ID != "" {
+		return emoji.Name + ":" + emoji.ID
+	}
+	return strings.Replace(emoji.Name, "\ufe0f", "", -1)
+}
+
 func ReactionAdd(discordUser *discordgo.User, rctn *discordgo.MessageReaction, timeStamp time.Time) {
-	reaction := Reaction{MessageID: rctn.MessageID, UserID: discordUser.String(), Content: rctn.Emoji.ID}
+	reaction := Reaction{MessageID: rctn.MessageID, UserID: discordUser.String(), Content: EmojiKey(rctn.Emoji)}
 
 	has, err := engine.Exist(&reaction)
 	if err != nil {
@@ -345,7 +651,7 @@ func GetUserLastReactionAddTime(giverID, receiverID string) (timeStamp time.Time
 }
 
 func ReactionRemove(discordUser *discordgo.User, rctn *discordgo.MessageReaction, timeStamp time.Time) {
-	reaction := Reaction{MessageID: rctn.MessageID, UserID: discordUser.String(), Content: rctn.Emoji.ID}
+	reaction := Reaction{MessageID: rctn.MessageID, UserID: discordUser.String(), Content: EmojiKey(rctn.Emoji)}
 
 	has, err := engine.Get(&reaction)
 	if err != nil {
@@ -384,6 +690,28 @@ func GetUserLastReactionRemoveTime(giverID, receiverID string) (timeStamp time.T
 	return
 }
 
+// GetInteractions Count the reactions still there and rewarded mentions between every pair of users in each guild since the given time
+func GetInteractions(since time.Time) (interactions []Interaction) {
+	err := engine.Table("Reaction").Alias("r").Select("c.GuildID AS GuildID, r.UserID AS GiverID, m.UserID AS ReceiverID, count(*) AS Count").
+		Join("INNER", []string{"Message", "m"}, "r.MessageID = m.ID").
+		Join("INNER", []string{"Channel", "c"}, "m.ChannelID = c.ID").
+		Where("r.Time > ?", since).And("r.UserID <> m.UserID").And("r.Removed IS NULL").
+		GroupBy("c.GuildID, r.UserID, m.UserID").
+		Find(&interactions)
+	if err != nil {
+		panic(err)
+	}
+
+	err = engine.Table("Mention").Select("GuildID, GiverID, ReceiverID, count(*) AS Count").
+		Where("Time > ?", since).And("Respec > 0").And("GuildID <> ''").
+		GroupBy("GuildID, GiverID, ReceiverID").
+		Find(&interactions)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
 func RecordBet(b DBBet, users []string) {
 	_, err := engine.Table("Bet").Insert(b)
 	if err != nil {
@@ -402,98 +730,885 @@ func RecordBet(b DBBet, users []string) {
 	}
 }
 
-func LoadActiveChannels(chanList *map[string]bool, guildList *map[string]bool) {
-	var channels []Channel
-
-	if err := engine.Find(&channels); err != nil {
+func LoadLexicon(guildID string, list *map[string]int) {
+	var words []LexiconWord
+	if err := engine.Where("GuildID = ?", guildID).Find(&words); err != nil {
 		panic(err)
 	}
-
-	for _, v := range channels {
-		if v.Active {
-			(*chanList)[v.ID] = v.Active
-			(*guildList)[v.GuildID] = v.Active
-		}
+	for _, v := range words {
+		(*list)[v.Word] = v.Score
 	}
 }
 
-func purgeDB() error {
-	engine.ShowSQL(true)
-	log.Println("Purging Database")
-	var users []User
-	var messages []Message
-	var reactions []Reaction
-	var mention []Mention
-	var channels []Channel
-	var dbbet []DBBet
-	var betusers []BetUsers
-	if err := engine.Find(&users); err != nil {
-		return err
-	}
-	for _, v := range users {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
-		}
-	}
-	if err := engine.Find(&messages); err != nil {
-		return err
+func SetLexiconWord(guildID, word string, score int) {
+	lexiconWord := &LexiconWord{GuildID: guildID, Word: word}
+	has, err := engine.Get(lexiconWord)
+	if err != nil {
+		panic(err)
 	}
-	for _, v := range messages {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
+	lexiconWord.Score = score
+	if has {
+		if _, err = engine.ID(core.PK{guildID, word}).Cols("Score").Update(lexiconWord); err != nil {
+			panic(err)
 		}
-	}
-	if err := engine.Find(&reactions); err != nil {
-		return err
-	}
-	for _, v := range reactions {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
+	} else {
+		if _, err = engine.Insert(lexiconWord); err != nil {
+			panic(err)
 		}
 	}
-	if err := engine.Find(&mention); err != nil {
-		return err
-	}
-	for _, v := range mention {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
-		}
+}
+
+func RemoveLexiconWord(guildID, word string) (removed bool) {
+	count, err := engine.Delete(&LexiconWord{GuildID: guildID, Word: word})
+	if err != nil {
+		panic(err)
 	}
-	if err := engine.Find(&channels); err != nil {
-		return err
+	return count > 0
+}
+
+func LoadGuildSettings(guildID string, list *map[string]string) {
+	var settings []GuildSetting
+	if err := engine.Where("GuildID = ?", guildID).Find(&settings); err != nil {
+		panic(err)
 	}
-	for _, v := range channels {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
-		}
+	for _, v := range settings {
+		(*list)[v.Name] = v.Value
 	}
-	if err := engine.Find(&dbbet); err != nil {
-		return err
+}
+
+func SetGuildSetting(guildID, name, value string) {
+	setting := &GuildSetting{GuildID: guildID, Name: name}
+	has, err := engine.Get(setting)
+	if err != nil {
+		panic(err)
 	}
-	for _, v := range dbbet {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
+	setting.Value = value
+	if has {
+		if _, err = engine.ID(core.PK{guildID, name}).Cols("Value").Update(setting); err != nil {
+			panic(err)
 		}
-	}
-	if err := engine.Find(&betusers); err != nil {
-		return err
-	}
-	for _, v := range betusers {
-		if _, err := engine.Delete(&v); err != nil {
-			return err
+	} else {
+		if _, err = engine.Insert(setting); err != nil {
+			panic(err)
 		}
 	}
-
-	return nil
 }
 
-type pair struct {
-	Key   string
-	Value int
+func RemoveGuildSetting(guildID, name string) {
+	if _, err := engine.Delete(&GuildSetting{GuildID: guildID, Name: name}); err != nil {
+		panic(err)
+	}
 }
 
-type pairList []pair
+func LoadGuildDomains(guildID string, list *map[string]bool) {
+	var domains []GuildDomain
+	if err := engine.Where("GuildID = ?", guildID).Find(&domains); err != nil {
+		panic(err)
+	}
+	for _, v := range domains {
+		(*list)[v.Domain] = v.Allowed
+	}
+}
+
+func SetGuildDomain(guildID, domain string, allowed bool) {
+	guildDomain := &GuildDomain{GuildID: guildID, Domain: domain}
+	has, err := engine.Get(guildDomain)
+	if err != nil {
+		panic(err)
+	}
+	guildDomain.Allowed = allowed
+	if has {
+		if _, err = engine.ID(core.PK{guildID, domain}).Cols("Allowed").Update(guildDomain); err != nil {
+			panic(err)
+		}
+	} else {
+		if _, err = engine.Insert(guildDomain); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func RemoveGuildDomain(guildID, domain string) (removed bool) {
+	count, err := engine.Delete(&GuildDomain{GuildID: guildID, Domain: domain})
+	if err != nil {
+		panic(err)
+	}
+	return count > 0
+}
+
+func LoadEmojiWeights(guildID string, list *map[string]int) {
+	var weights []EmojiWeight
+	if err := engine.Where("GuildID = ?", guildID).Find(&weights); err != nil {
+		panic(err)
+	}
+	for _, v := range weights {
+		(*list)[v.Emoji] = v.Weight
+	}
+}
+
+func SetEmojiWeight(guildID, emoji string, weight int) {
+	emojiWeight := &EmojiWeight{GuildID: guildID, Emoji: emoji}
+	has, err := engine.Get(emojiWeight)
+	if err != nil {
+		panic(err)
+	}
+	emojiWeight.Weight = weight
+	if has {
+		if _, err = engine.ID(core.PK{guildID, emoji}).Cols("Weight").Update(emojiWeight); err != nil {
+			panic(err)
+		}
+	} else {
+		if _, err = engine.Insert(emojiWeight); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func RemoveEmojiWeight(guildID, emoji string) (removed bool) {
+	count, err := engine.Delete(&EmojiWeight{GuildID: guildID, Emoji: emoji})
+	if err != nil {
+		panic(err)
+	}
+	return count > 0
+}
+
+func GetTiers(guildID string) (tiers []Tier) {
+	if err := engine.Where("GuildID = ?", guildID).Asc("ID").Find(&tiers); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func AddTier(tier *Tier) {
+	if _, err := engine.Insert(tier); err != nil {
+		panic(err)
+	}
+}
+
+func RemoveTier(guildID string, tierID uint64) (removed bool) {
+	count, err := engine.Delete(&Tier{GuildID: guildID, ID: tierID})
+	if err != nil {
+		panic(err)
+	}
+	return count > 0
+}
+
+func GetCurrentSeason(guildID string) (season Season, ok bool) {
+	has, err := engine.Where("GuildID = ? AND Ended = ?", guildID, false).Desc("Number").Get(&season)
+	if err != nil {
+		panic(err)
+	}
+	return season, has
+}
+
+func AddSeason(season *Season) {
+	if _, err := engine.Insert(season); err != nil {
+		panic(err)
+	}
+}
+
+// archive the final standings and close the season together
+func EndSeason(season *Season, standings []SeasonStanding) {
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		panic(err)
+	}
+	for _, v := range standings {
+		if _, err := session.Insert(&v); err != nil {
+			session.Rollback()
+			panic(err)
+		}
+	}
+	season.Ended = true
+	if _, err := session.ID(season.ID).Cols("Ended", "End").Update(season); err != nil {
+		session.Rollback()
+		panic(err)
+	}
+	if err := session.Commit(); err != nil {
+		panic(err)
+	}
+}
+
+func GetEndedSeasons(guildID string, limit int) (seasons []Season) {
+	if err := engine.Where("GuildID = ? AND Ended = ?", guildID, true).Desc("Number").Limit(limit).Find(&seasons); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// a limit of 0 gets everyone
+func GetSeasonStandings(seasonID uint64, limit int) (standings []SeasonStanding) {
+	session := engine.Where("SeasonID = ?", seasonID).Asc("Rank")
+	if limit > 0 {
+		session = session.Limit(limit)
+	}
+	if err := session.Find(&standings); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// false if the user already had it
+func AddAchievement(achievement UserAchievement) (added bool) {
+	has, err := engine.Exist(&UserAchievement{UserID: achievement.UserID, Achievement: achievement.Achievement})
+	if err != nil {
+		panic(err)
+	}
+	if has {
+		return false
+	}
+	if _, err = engine.Insert(&achievement); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+func GetUserAchievements(userID string) (achievements []UserAchievement) {
+	if err := engine.Where("UserID = ?", userID).Asc("Time").Find(&achievements); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func CountBetsWon(userID string) int {
+	count, err := engine.Where("Winner = ?", userID).Count(&DBBet{})
+	if err != nil {
+		panic(err)
+	}
+	return int(count)
+}
+
+func GetStreak(userID, guildID string) (streak Streak) {
+	streak = Streak{UserID: userID, GuildID: guildID}
+	if _, err := engine.Get(&streak); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func SetStreak(streak Streak) {
+	has, err := engine.Exist(&Streak{UserID: streak.UserID, GuildID: streak.GuildID})
+	if err != nil {
+		panic(err)
+	}
+	if has {
+		_, err = engine.ID(core.PK{streak.UserID, streak.GuildID}).AllCols().Update(&streak)
+	} else {
+		_, err = engine.Insert(&streak)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// streaks that were kept up as recently as the given day
+func GetStreaksSince(guildID, day string, minimum int) (streaks []Streak) {
+	if err := engine.Where("GuildID = ? AND LastDay >= ? AND Current >= ?", guildID, day, minimum).Find(&streaks); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// take respec from one user and give it to another along with the history and record of it, all or nothing
+func TransferRespec(transfer *Transfer, toUsername string, toIsNew bool, reason string) {
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		panic(err)
+	}
+	fail := func(err error) {
+		session.Rollback()
+		panic(err)
+	}
+
+	if _, err := session.Table("User").Where("ID = ?", transfer.FromID).Incr("Respec", -transfer.Amount).Update(&User{}); err != nil {
+		fail(err)
+	}
+	if toIsNew {
+		if _, err := session.Insert(&User{ID: transfer.ToID, Username: toUsername, Respec: transfer.Amount}); err != nil {
+			fail(err)
+		}
+	} else if _, err := session.Table("User").Where("ID = ?", transfer.ToID).Incr("Respec", transfer.Amount).Update(&User{}); err != nil {
+		fail(err)
+	}
+	if _, err := session.Insert(transfer); err != nil {
+		fail(err)
+	}
+
+	ref := fmt.Sprint(transfer.ID)
+	history := []History{
+		{UserID: transfer.FromID, GuildID: transfer.GuildID, Respec: -transfer.Amount, Reason: reason, Ref: ref, Time: transfer.Time},
+		{UserID: transfer.ToID, GuildID: transfer.GuildID, Respec: transfer.Amount, Reason: reason, Ref: ref, Time: transfer.Time},
+	}
+	for _, v := range history {
+		if _, err := session.Insert(&v); err != nil {
+			fail(err)
+		}
+	}
+
+	if err := session.Commit(); err != nil {
+		panic(err)
+	}
+}
+
+// how much a user has given and received in a guild since the given time
+func GetTransferTotals(guildID, userID string, since time.Time) (given, received int) {
+	var transfers []Transfer
+	if err := engine.Where("GuildID = ? AND (FromID = ? OR ToID = ?) AND Time >= ?", guildID, userID, userID, since).Find(&transfers); err != nil {
+		panic(err)
+	}
+	for _, v := range transfers {
+		if v.FromID == userID {
+			given += v.Amount
+		} else {
+			received += v.Amount
+		}
+	}
+	return
+}
+
+func GetShopItems(guildID string) (items []ShopItem) {
+	if err := engine.Where("GuildID = ?", guildID).Asc("ID").Find(&items); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func GetShopItem(guildID string, itemID uint64) (item ShopItem, ok bool) {
+	has, err := engine.Where("GuildID = ? AND ID = ?", guildID, itemID).Get(&item)
+	if err != nil {
+		panic(err)
+	}
+	return item, has
+}
+
+func AddShopItem(item *ShopItem) {
+	if _, err := engine.Insert(item); err != nil {
+		panic(err)
+	}
+}
+
+func RemoveShopItem(guildID string, itemID uint64) (removed bool) {
+	count, err := engine.Delete(&ShopItem{GuildID: guildID, ID: itemID})
+	if err != nil {
+		panic(err)
+	}
+	return count > 0
+}
+
+// charge the user, take one from stock and record the purchase all at once, false if it sold out
+func BuyItem(purchase *Purchase, reason string) (bought bool) {
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		panic(err)
+	}
+	fail := func(err error) {
+		session.Rollback()
+		panic(err)
+	}
+
+	// only items with stock left get taken from
+	count, err := session.Table("ShopItem").Where("ID = ? AND Stock > 0", purchase.ItemID).Decr("Stock", 1).Update(&ShopItem{})
+	if err != nil {
+		fail(err)
+	}
+	if count == 0 {
+		var item ShopItem
+		has, err := session.ID(purchase.ItemID).Get(&item)
+		if err != nil {
+			fail(err)
+		}
+		if !has || item.Stock == 0 {
+			session.Rollback()
+			return false
+		}
+	}
+
+	if _, err = session.Table("User").Where("ID = ?", purchase.UserID).Incr("Respec", -purchase.Price).Update(&User{}); err != nil {
+		fail(err)
+	}
+	if _, err = session.Insert(purchase); err != nil {
+		fail(err)
+	}
+	history := History{UserID: purchase.UserID, GuildID: purchase.GuildID, Respec: -purchase.Price, Reason: reason, Ref: fmt.Sprint(purchase.ID), Time: purchase.Time}
+	if _, err = session.Insert(&history); err != nil {
+		fail(err)
+	}
+
+	if err = session.Commit(); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// SetPurchaseValue Remember what applying a purchase did so it can be undone
+func SetPurchaseValue(purchaseID uint64, value, oldValue string) {
+	if _, err := engine.ID(purchaseID).Cols("Value", "OldValue").Update(&Purchase{Value: value, OldValue: oldValue}); err != nil {
+		panic(err)
+	}
+}
+
+// RefundPurchase Put a purchase back on the shelf and record the refund, the user's respec is given back through the cache
+func RefundPurchase(purchase Purchase, reason string) {
+	session := engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		panic(err)
+	}
+	fail := func(err error) {
+		session.Rollback()
+		panic(err)
+	}
+
+	// items without a limit have no stock to give back
+	if _, err := session.Table("ShopItem").Where("ID = ? AND Stock >= 0", purchase.ItemID).Incr("Stock", 1).Update(&ShopItem{}); err != nil {
+		fail(err)
+	}
+	if _, err := session.ID(purchase.ID).Cols("Expired").Update(&Purchase{Expired: true}); err != nil {
+		fail(err)
+	}
+	history := History{UserID: purchase.UserID, GuildID: purchase.GuildID, Respec: purchase.Price, Reason: reason, Ref: fmt.Sprint(purchase.ID), Time: time.Now()}
+	if _, err := session.Insert(&history); err != nil {
+		fail(err)
+	}
+
+	if err := session.Commit(); err != nil {
+		panic(err)
+	}
+}
+
+// purchases that haven't run out, an empty guildID looks in every guild
+func GetActivePurchases(guildID, userID string) (purchases []Purchase) {
+	session := engine.Where("UserID = ? AND Expired = ?", userID, false)
+	if guildID != "" {
+		session = session.And("GuildID = ?", guildID)
+	}
+	if err := session.Asc("Time").Find(&purchases); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func GetExpiredPurchases(now time.Time) (purchases []Purchase) {
+	if err := engine.Where("Expired = ? AND Expires IS NOT NULL AND Expires <= ?", false, now).Find(&purchases); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func ExpirePurchase(purchaseID uint64) {
+	if _, err := engine.ID(purchaseID).Cols("Expired").Update(&Purchase{Expired: true}); err != nil {
+		panic(err)
+	}
+}
+
+// GetUserTimezone The timezone a user picked if they picked one
+func GetUserTimezone(userID string) (timezone string, ok bool) {
+	var setting UserTimezone
+	has, err := engine.Where("UserID = ?", userID).Get(&setting)
+	if err != nil {
+		panic(err)
+	}
+	return setting.Timezone, has
+}
+
+// SetUserTimezone Remember a user's timezone, empty forgets it
+func SetUserTimezone(userID, timezone string) {
+	if _, err := engine.Delete(&UserTimezone{UserID: userID}); err != nil {
+		panic(err)
+	}
+	if timezone == "" {
+		return
+	}
+	if _, err := engine.Insert(&UserTimezone{UserID: userID, Timezone: timezone}); err != nil {
+		panic(err)
+	}
+}
+
+// GetMessageRespec What a message has changed a user's respec by in a guild, leaving out some reasons
+func GetMessageRespec(guildID, userID, messageID string, ignore ...string) int {
+	var history []History
+	if err := engine.Where("GuildID = ? AND UserID = ? AND Ref = ?", guildID, userID, messageID).NotIn("Reason", ignore).Find(&history); err != nil {
+		panic(err)
+	}
+	respec := 0
+	for _, v := range history {
+		respec += v.Respec
+	}
+	return respec
+}
+
+// AddAppeal Open an appeal, false if the user already appealed the message
+func AddAppeal(appeal *Appeal) bool {
+	has, err := engine.Where("UserID = ? AND MessageID = ?", appeal.UserID, appeal.MessageID).Exist(&Appeal{})
+	if err != nil {
+		panic(err)
+	}
+	if has {
+		return false
+	}
+	if _, err := engine.Insert(appeal); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+func GetAppeal(appealID uint64) (appeal Appeal, ok bool) {
+	has, err := engine.Id(appealID).Get(&appeal)
+	if err != nil {
+		panic(err)
+	}
+	return appeal, has
+}
+
+// GetOpenAppeals Appeals still being voted on in a channel, oldest first
+func GetOpenAppeals(channelID string) (appeals []Appeal) {
+	if err := engine.Where("ChannelID = ? AND Status = ?", channelID, "open").Asc("ID").Find(&appeals); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// GetDueAppeals Open appeals whose vote is over
+func GetDueAppeals(now time.Time) (appeals []Appeal) {
+	if err := engine.Where("Status = ? AND Closes <= ?", "open", now).Find(&appeals); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// AddAppealVote Record a vote, false if the user already voted on the appeal
+func AddAppealVote(vote AppealVote) bool {
+	has, err := engine.Exist(&AppealVote{AppealID: vote.AppealID, UserID: vote.UserID})
+	if err != nil {
+		panic(err)
+	}
+	if has {
+		return false
+	}
+	if _, err := engine.Insert(&vote); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+func GetAppealVotes(appealID uint64) (votes []AppealVote) {
+	if err := engine.Where("AppealID = ?", appealID).Find(&votes); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// CloseAppeal Record how an appeal's vote went
+func CloseAppeal(appeal Appeal) {
+	if _, err := engine.Id(appeal.ID).Cols("Status", "Yes", "No").Update(&appeal); err != nil {
+		panic(err)
+	}
+}
+
+// CountFailedAppeals How many of a user's appeals in a guild failed since a time
+func CountFailedAppeals(guildID, userID string, since time.Time) int {
+	count, err := engine.Where("GuildID = ? AND UserID = ? AND Status = ? AND Opened >= ?", guildID, userID, "failed", since).Count(new(Appeal))
+	if err != nil {
+		panic(err)
+	}
+	return int(count)
+}
+
+func AddVote(vote Vote) {
+	if _, err := engine.Insert(&vote); err != nil {
+		panic(err)
+	}
+}
+
+// CountVotesSince How many votes a user has given in a guild since a time
+func CountVotesSince(guildID, giverID string, since time.Time) int {
+	count, err := engine.Where("GuildID = ? AND GiverID = ? AND Time >= ?", guildID, giverID, since).Count(new(Vote))
+	if err != nil {
+		panic(err)
+	}
+	return int(count)
+}
+
+// GetVotesReceived The latest votes a user got, newest first
+func GetVotesReceived(receiverID string, limit int) (votes []Vote) {
+	if err := engine.Where("ReceiverID = ?", receiverID).Desc("Time").Limit(limit).Find(&votes); err != nil {
+		panic(err)
+	}
+	return
+}
+
+// GetVoteTotals How many ++ and -- a user has got
+func GetVoteTotals(receiverID string) (up, down int) {
+	upCount, err := engine.Where("ReceiverID = ? AND Respec > 0", receiverID).Count(new(Vote))
+	if err != nil {
+		panic(err)
+	}
+	downCount, err := engine.Where("ReceiverID = ? AND Respec < 0", receiverID).Count(new(Vote))
+	if err != nil {
+		panic(err)
+	}
+	return int(upCount), int(downCount)
+}
+
+func LoadActiveChannels(chanList *map[string]bool, guildList *map[string]bool) {
+	var channels []Channel
+
+	if err := engine.Find(&channels); err != nil {
+		panic(err)
+	}
+
+	for _, v := range channels {
+		if v.Active {
+			(*chanList)[v.ID] = v.Active
+			(*guildList)[v.GuildID] = v.Active
+		}
+	}
+}
+
+func purgeDB() error {
+	engine.ShowSQL(true)
+	log.Println("Purging Database")
+	var users []User
+	var messages []Message
+	var reactions []Reaction
+	var mention []Mention
+	var channels []Channel
+	var dbbet []DBBet
+	var betusers []BetUsers
+	var lexiconWords []LexiconWord
+	var guildSettings []GuildSetting
+	var guildDomains []GuildDomain
+	var emojiWeights []EmojiWeight
+	var history []History
+	var flips []Flip
+	var flipSeeds []FlipSeed
+	var tiers []Tier
+	var seasons []Season
+	var seasonStandings []SeasonStanding
+	var userAchievements []UserAchievement
+	var streaks []Streak
+	var transfers []Transfer
+	var shopItems []ShopItem
+	var purchases []Purchase
+	var userTimezone []UserTimezone
+	var appeal []Appeal
+	var appealVote []AppealVote
+	var vote []Vote
+	if err := engine.Find(&users); err != nil {
+		return err
+	}
+	for _, v := range users {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&messages); err != nil {
+		return err
+	}
+	for _, v := range messages {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&reactions); err != nil {
+		return err
+	}
+	for _, v := range reactions {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&mention); err != nil {
+		return err
+	}
+	for _, v := range mention {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&channels); err != nil {
+		return err
+	}
+	for _, v := range channels {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&dbbet); err != nil {
+		return err
+	}
+	for _, v := range dbbet {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&betusers); err != nil {
+		return err
+	}
+	for _, v := range betusers {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&lexiconWords); err != nil {
+		return err
+	}
+	for _, v := range lexiconWords {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&guildSettings); err != nil {
+		return err
+	}
+	for _, v := range guildSettings {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&guildDomains); err != nil {
+		return err
+	}
+	for _, v := range guildDomains {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&emojiWeights); err != nil {
+		return err
+	}
+	for _, v := range emojiWeights {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&history); err != nil {
+		return err
+	}
+	for _, v := range history {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&flips); err != nil {
+		return err
+	}
+	for _, v := range flips {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&flipSeeds); err != nil {
+		return err
+	}
+	for _, v := range flipSeeds {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&tiers); err != nil {
+		return err
+	}
+	for _, v := range tiers {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&seasons); err != nil {
+		return err
+	}
+	for _, v := range seasons {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&seasonStandings); err != nil {
+		return err
+	}
+	for _, v := range seasonStandings {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&userAchievements); err != nil {
+		return err
+	}
+	for _, v := range userAchievements {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&streaks); err != nil {
+		return err
+	}
+	for _, v := range streaks {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&transfers); err != nil {
+		return err
+	}
+	for _, v := range transfers {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&shopItems); err != nil {
+		return err
+	}
+	for _, v := range shopItems {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&purchases); err != nil {
+		return err
+	}
+	for _, v := range purchases {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&userTimezone); err != nil {
+		return err
+	}
+	for _, v := range userTimezone {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&appeal); err != nil {
+		return err
+	}
+	for _, v := range appeal {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&appealVote); err != nil {
+		return err
+	}
+	for _, v := range appealVote {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
+	if err := engine.Find(&vote); err != nil {
+		return err
+	}
+	for _, v := range vote {
+		if _, err := engine.Delete(&v); err != nil {
+			return err
+		}
+	}
 
-func (p pairList) Len() int           { return len(p) }
-func (p pairList) Less(i, j int) bool { return p[i].Value < p[j].Value }
-func (p pairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+	return nil
+}
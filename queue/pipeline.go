@@ -0,0 +1,234 @@
+package queue
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// Overload What a pipeline does with a job when the queue it belongs in is full, jobs pushed with PushWait are never thrown away
+type Overload int
+
+// Overload policies
+const (
+	// wait for room, this slows down whoever is pushing
+	Block Overload = iota
+	// throw away the job being pushed
+	DropNewest
+	// throw away the oldest job waiting in the same queue that can be thrown away to make room
+	DropOldest
+)
+
+// ErrOverloaded The job was thrown away because the pipeline was full
+var ErrOverloaded = fmt.Errorf("Pipeline is overloaded")
+
+// ErrClosed The pipeline isn't taking jobs anymore
+var ErrClosed = fmt.Errorf("Pipeline is closed")
+
+// Stats What a pipeline has been up to
+type Stats struct {
+	Workers   int
+	Capacity  int
+	Depth     int64
+	MaxDepth  int64
+	Processed int64
+	Dropped   int64
+	Failed    int64
+}
+
+// Pipeline Runs jobs on a pool of workers, jobs with the same key run one at a time in the order they were pushed
+type Pipeline struct {
+	shards  []*shard
+	size    int
+	policy  Overload
+	onPanic func(interface{})
+
+	depth     int64
+	maxDepth  int64
+	processed int64
+	dropped   int64
+	failed    int64
+
+	mux    sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// a job and whether the overload policy is allowed to throw it away
+type task struct {
+	job  func()
+	keep bool
+}
+
+// one worker's queue
+type shard struct {
+	mux    sync.Mutex
+	cond   *sync.Cond
+	tasks  []task
+	closed bool
+}
+
+// NewPipeline Start workers that each queue up to size jobs, onPanic is told about jobs that panic and can be nil
+func NewPipeline(workers, size int, policy Overload, onPanic func(interface{})) (p *Pipeline, err error) {
+	if workers < 1 || size < 1 {
+		return nil, fmt.Errorf("Pipeline needs at least 1 worker and a queue size of at least 1")
+	}
+
+	p = &Pipeline{size: size, policy: policy, onPanic: onPanic}
+	for i := 0; i < workers; i++ {
+		s := new(shard)
+		s.cond = sync.NewCond(&s.mux)
+		p.shards = append(p.shards, s)
+		p.wg.Add(1)
+		go p.work(s)
+	}
+	return p, nil
+}
+
+// every key always goes to the same worker, that's what keeps them in order
+func (p *Pipeline) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return p.shards[h.Sum32()%uint32(len(p.shards))]
+}
+
+// Push Queue a job, it's run after every job pushed before it with the same key
+func (p *Pipeline) Push(key string, job func()) error {
+	return p.push(key, task{job: job})
+}
+
+// PushWait Queue a job that can't be thrown away, it waits for room whatever the overload policy is
+func (p *Pipeline) PushWait(key string, job func()) error {
+	return p.push(key, task{job: job, keep: true})
+}
+
+func (p *Pipeline) push(key string, t task) error {
+	p.mux.Lock()
+	closed := p.closed
+	p.mux.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	s := p.shard(key)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for len(s.tasks) >= p.size && !s.closed {
+		if !t.keep && p.policy == DropNewest {
+			atomic.AddInt64(&p.dropped, 1)
+			return ErrOverloaded
+		}
+		if !t.keep && p.policy == DropOldest {
+			// when everything waiting has to be kept this job is the oldest that doesn't
+			if !s.dropOldest() {
+				atomic.AddInt64(&p.dropped, 1)
+				return ErrOverloaded
+			}
+			atomic.AddInt64(&p.depth, -1)
+			atomic.AddInt64(&p.dropped, 1)
+			continue
+		}
+		s.cond.Wait()
+	}
+	if s.closed {
+		return ErrClosed
+	}
+
+	s.tasks = append(s.tasks, t)
+	depth := atomic.AddInt64(&p.depth, 1)
+	for max := atomic.LoadInt64(&p.maxDepth); depth > max; max = atomic.LoadInt64(&p.maxDepth) {
+		if atomic.CompareAndSwapInt64(&p.maxDepth, max, depth) {
+			break
+		}
+	}
+	s.cond.Broadcast()
+	return nil
+}
+
+// throw away the oldest waiting job that's allowed to be, lock has to be held
+func (s *shard) dropOldest() bool {
+	for i, v := range s.tasks {
+		if !v.keep {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// the next job, false once the shard is closed and empty
+func (s *shard) next() (t task, ok bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for len(s.tasks) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.tasks) == 0 {
+		return t, false
+	}
+	t = s.tasks[0]
+	s.tasks = s.tasks[1:]
+	s.cond.Broadcast()
+	return t, true
+}
+
+func (p *Pipeline) work(s *shard) {
+	defer p.wg.Done()
+	for {
+		t, ok := s.next()
+		if !ok {
+			return
+		}
+		atomic.AddInt64(&p.depth, -1)
+		p.run(t.job)
+	}
+}
+
+// one bad job shouldn't take the worker down with it
+func (p *Pipeline) run(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&p.failed, 1)
+			if p.onPanic != nil {
+				p.onPanic(r)
+			}
+		}
+	}()
+	job()
+	atomic.AddInt64(&p.processed, 1)
+}
+
+// Stats How the pipeline is doing right now
+func (p *Pipeline) Stats() Stats {
+	return Stats{
+		Workers:   len(p.shards),
+		Capacity:  len(p.shards) * p.size,
+		Depth:     atomic.LoadInt64(&p.depth),
+		MaxDepth:  atomic.LoadInt64(&p.maxDepth),
+		Processed: atomic.LoadInt64(&p.processed),
+		Dropped:   atomic.LoadInt64(&p.dropped),
+		Failed:    atomic.LoadInt64(&p.failed),
+	}
+}
+
+// Close Stop taking jobs and wait for the queued ones to finish
+func (p *Pipeline) Close() {
+	p.mux.Lock()
+	if p.closed {
+		p.mux.Unlock()
+		return
+	}
+	p.closed = true
+	p.mux.Unlock()
+
+	for _, s := range p.shards {
+		s.mux.Lock()
+		s.closed = true
+		s.cond.Broadcast()
+		s.mux.Unlock()
+	}
+	p.wg.Wait()
+}
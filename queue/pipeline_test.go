@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestPipelineOrder(t *testing.T) {
+	p, err := NewPipeline(4, 10, Block, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mux sync.Mutex
+	got := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			key, i := key, i
+			p.Push(key, func() {
+				mux.Lock()
+				got[key] = append(got[key], i)
+				mux.Unlock()
+			})
+		}
+	}
+	p.Close()
+
+	for key, v := range got {
+		if len(v) != 100 {
+			t.Errorf("%v: wanted 100 jobs, got %v", key, len(v))
+		}
+		for i := range v {
+			if v[i] != i {
+				t.Errorf("%v: jobs ran out of order %v", key, v)
+				break
+			}
+		}
+	}
+	if stats := p.Stats(); stats.Processed != 500 || stats.Depth != 0 {
+		t.Errorf("Wrong stats %+v", stats)
+	}
+	if err = p.Push("a", func() {}); err != ErrClosed {
+		t.Errorf("Closed pipeline took a job")
+	}
+}
+
+// one worker stuck on a job with a queue of 2
+func stuckPipeline(t *testing.T, policy Overload) (p *Pipeline, release chan bool, ran *[]string) {
+	p, err := NewPipeline(1, 2, policy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release = make(chan bool)
+	started := make(chan bool)
+	ran = new([]string)
+	p.Push("x", func() {
+		started <- true
+		<-release
+	})
+	<-started
+	return
+}
+
+func TestPipelineDropNewest(t *testing.T) {
+	p, release, ran := stuckPipeline(t, DropNewest)
+	for i := 0; i < 4; i++ {
+		i := i
+		err := p.Push("x", func() { *ran = append(*ran, fmt.Sprint(i)) })
+		if (i < 2) != (err == nil) {
+			t.Errorf("Job %v: unexpected error %v", i, err)
+		}
+	}
+	close(release)
+	p.Close()
+
+	if fmt.Sprint(*ran) != "[0 1]" {
+		t.Errorf("Wanted jobs [0 1], got %v", *ran)
+	}
+	if stats := p.Stats(); stats.Dropped != 2 || stats.MaxDepth != 2 {
+		t.Errorf("Wrong stats %+v", stats)
+	}
+}
+
+func TestPipelineDropOldest(t *testing.T) {
+	p, release, ran := stuckPipeline(t, DropOldest)
+	for i := 0; i < 4; i++ {
+		i := i
+		if err := p.Push("x", func() { *ran = append(*ran, fmt.Sprint(i)) }); err != nil {
+			t.Errorf("Job %v: unexpected error %v", i, err)
+		}
+	}
+	close(release)
+	p.Close()
+
+	if fmt.Sprint(*ran) != "[2 3]" {
+		t.Errorf("Wanted jobs [2 3], got %v", *ran)
+	}
+	if stats := p.Stats(); stats.Dropped != 2 {
+		t.Errorf("Wrong stats %+v", stats)
+	}
+}
+
+func TestPipelinePushWait(t *testing.T) {
+	for _, policy := range []Overload{DropNewest, DropOldest} {
+		p, release, ran := stuckPipeline(t, policy)
+		var mux sync.Mutex
+		record := func(name string) func() {
+			return func() {
+				mux.Lock()
+				*ran = append(*ran, name)
+				mux.Unlock()
+			}
+		}
+
+		p.PushWait("x", record("keep1"))
+		p.PushWait("x", record("keep2"))
+		if err := p.Push("x", record("drop")); err != ErrOverloaded {
+			t.Errorf("%v: queue full of kept jobs took another", policy)
+		}
+
+		waited := make(chan bool)
+		go func() {
+			p.PushWait("x", record("keep3"))
+			waited <- true
+		}()
+		close(release)
+		<-waited
+		p.Close()
+
+		if fmt.Sprint(*ran) != "[keep1 keep2 keep3]" {
+			t.Errorf("%v: wanted every kept job in order, got %v", policy, *ran)
+		}
+	}
+}
+
+func TestPipelinePanic(t *testing.T) {
+	var recovered interface{}
+	p, _ := NewPipeline(1, 5, Block, func(r interface{}) { recovered = r })
+
+	ran := false
+	p.Push("a", func() { panic("oops") })
+	p.Push("a", func() { ran = true })
+	p.Close()
+
+	if recovered != "oops" || !ran {
+		t.Errorf("Worker didn't survive a panic")
+	}
+	if stats := p.Stats(); stats.Failed != 1 || stats.Processed != 1 {
+		t.Errorf("Wrong stats %+v", stats)
+	}
+
+	if _, err := NewPipeline(0, 5, Block, nil); err == nil {
+		t.Errorf("Could create pipeline with no workers")
+	}
+}
@@ -0,0 +1,232 @@
+package rate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	appealInterval = time.Minute
+	appealOpen     = "open"
+	appealPassed   = "passed"
+	appealFailed   = "failed"
+)
+
+var appealMux sync.Mutex
+
+func init() {
+	config.Register("appeal.minutes", "30", "minutes an appeal is open for votes")
+	config.Register("appeal.votes", "3", "votes in favour an appeal needs to pass, it also needs more for than against")
+	config.Register("appeal.minrespec", "10", "respec a user needs to vote on appeals")
+	config.Register("appeal.failures", "3", "failed appeals a user can file in appeal.days before they cost respec")
+	config.Register("appeal.days", "30", "days failed appeals are counted over")
+	config.Register("appeal.penalty", "5", "respec lost for each failed appeal over the limit")
+}
+
+// the message ID from an ID or a link to the message
+func parseMessageID(arg string) string {
+	arg = strings.Trim(arg, "<>/")
+	if i := strings.LastIndex(arg, "/"); i >= 0 {
+		arg = arg[i+1:]
+	}
+	if _, err := strconv.ParseUint(arg, 10, 64); err != nil {
+		return ""
+	}
+	return arg
+}
+
+// enough people said yes and more said yes than no
+func appealVotePassed(yes, no, minimum int) bool {
+	return yes >= minimum && yes > no
+}
+
+// respec lost for a failed appeal when the user has failed this many recently
+func appealPenalty(failed, allowed, penalty int) int {
+	if failed > allowed {
+		return penalty
+	}
+	return 0
+}
+
+// close appeals whose vote is over, even ones from before a restart
+func resolveAppeals() {
+	ticker := time.NewTicker(appealInterval)
+	for range ticker.C {
+		for _, v := range db.GetDueAppeals(time.Now()) {
+			closeAppeal(v)
+		}
+	}
+}
+
+// respec that isn't flipped, appeals undo a change exactly
+func appealRespec(guildID, userID string, respec int, ref string) {
+	score.AddByID(userID, respec)
+	db.AddHistory(db.History{UserID: userID, GuildID: guildID, Respec: respec, Reason: reasonAppeal, Ref: ref, Time: time.Now()})
+	scheduleReconcile(guildID)
+}
+
+func closeAppeal(appeal db.Appeal) {
+	appealMux.Lock()
+	defer appealMux.Unlock()
+
+	for _, v := range db.GetAppealVotes(appeal.ID) {
+		if v.InFavour {
+			appeal.Yes++
+		} else {
+			appeal.No++
+		}
+	}
+
+	reply := fmt.Sprintf("Appeal %v for <@%v> ", appeal.ID, appeal.UserID)
+	if appealVotePassed(appeal.Yes, appeal.No, config.Int(appeal.GuildID, "appeal.votes")) {
+		appeal.Status = appealPassed
+		db.CloseAppeal(appeal)
+		appealRespec(appeal.GuildID, appeal.UserID, -appeal.Respec, appeal.MessageID)
+		reply += fmt.Sprintf("passed %v to %v, they got their %v respec back", appeal.Yes, appeal.No, -appeal.Respec)
+	} else {
+		appeal.Status = appealFailed
+		db.CloseAppeal(appeal)
+		reply += fmt.Sprintf("failed %v to %v", appeal.Yes, appeal.No)
+
+		since := time.Now().Add(-time.Duration(config.Int(appeal.GuildID, "appeal.days")) * day)
+		failed := db.CountFailedAppeals(appeal.GuildID, appeal.UserID, since)
+		if penalty := appealPenalty(failed, config.Int(appeal.GuildID, "appeal.failures"), config.Int(appeal.GuildID, "appeal.penalty")); penalty > 0 {
+			appealRespec(appeal.GuildID, appeal.UserID, -penalty, appeal.MessageID)
+			reply += fmt.Sprintf(", that's %v failed appeals lately so it cost them %v respec", failed, penalty)
+		}
+	}
+
+	logging.Log(reply)
+	state.SendReply(appeal.ChannelID, reply)
+}
+
+// AppealCmd Ask everyone to undo what a message cost you, or vote on someone else's appeal
+func AppealCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || args[1] == "help" {
+		reply := "```\n"
+		reply += "'appeal [message id or link]' - ask everyone to give back what a message cost you\n"
+		reply += "'appeal list' - show the appeals open in this channel\n"
+		reply += "'appeal yes [appeal]' - vote to give the respec back\n"
+		reply += "'appeal no [appeal]' - vote against it\n"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	switch args[1] {
+	case "list":
+		listAppeals(message.ChannelID, guildID)
+	case "yes", "no":
+		voteAppeal(message, guildID, args[1] == "yes", args[2:])
+	default:
+		openAppeal(message, guildID, args[1])
+	}
+}
+
+func openAppeal(message *discordgo.Message, guildID, arg string) {
+	messageID := parseMessageID(arg)
+	if messageID == "" {
+		state.SendReply(message.ChannelID, "That's not a message")
+		return
+	}
+
+	if messageGuild, ok := db.GetMessageGuild(messageID); !ok || messageGuild != guildID {
+		state.SendReply(message.ChannelID, "That message isn't from this server")
+		return
+	}
+
+	appealMux.Lock()
+	defer appealMux.Unlock()
+
+	respec := db.GetMessageRespec(guildID, message.Author.ID, messageID, reasonAppeal)
+	if respec >= 0 {
+		state.SendReply(message.ChannelID, "That message didn't cost you anything")
+		return
+	}
+
+	now := time.Now()
+	appeal := db.Appeal{GuildID: guildID, ChannelID: message.ChannelID, UserID: message.Author.ID, MessageID: messageID,
+		Respec: respec, Status: appealOpen, Opened: now, Closes: now.Add(time.Duration(config.Int(guildID, "appeal.minutes")) * time.Minute)}
+	if !db.AddAppeal(&appeal) {
+		state.SendReply(message.ChannelID, "You already appealed that message")
+		return
+	}
+
+	logging.Log(fmt.Sprintf("%v appealed %v respec from %v", message.Author, respec, messageID))
+	state.SendReply(message.ChannelID, fmt.Sprintf("Appeal %v: %v wants back the %v respec message %v cost them. "+
+		"Anyone with %v respec can vote `appeal yes %v` or `appeal no %v` before %v",
+		appeal.ID, message.Author.Username, -respec, messageID, config.Int(guildID, "appeal.minrespec"),
+		appeal.ID, appeal.ID, LocalTime(appeal.Closes, Location(guildID, ""), "15:04")))
+}
+
+func voteAppeal(message *discordgo.Message, guildID string, inFavour bool, args []string) {
+	appealMux.Lock()
+	defer appealMux.Unlock()
+
+	var appeal db.Appeal
+	if len(args) > 0 {
+		appealID, err := strconv.ParseUint(args[0], 10, 64)
+		found := false
+		if err == nil {
+			appeal, found = db.GetAppeal(appealID)
+		}
+		if !found || appeal.ChannelID != message.ChannelID || appeal.Status != appealOpen {
+			state.SendReply(message.ChannelID, "There's no appeal like that open here")
+			return
+		}
+	} else if open := db.GetOpenAppeals(message.ChannelID); len(open) == 1 {
+		appeal = open[0]
+	} else {
+		state.SendReply(message.ChannelID, "Which appeal? `appeal list` shows them")
+		return
+	}
+
+	if appeal.UserID == message.Author.ID {
+		state.SendReply(message.ChannelID, "You can't vote on your own appeal")
+		return
+	}
+	if minimum := config.Int(guildID, "appeal.minrespec"); score.GetByID(message.Author.ID) < minimum {
+		state.SendReply(message.ChannelID, fmt.Sprintf("You need %v respec to vote", minimum))
+		return
+	}
+	if !time.Now().Before(appeal.Closes) {
+		state.SendReply(message.ChannelID, "Voting on that appeal is over")
+		return
+	}
+	if !db.AddAppealVote(db.AppealVote{AppealID: appeal.ID, UserID: message.Author.ID, InFavour: inFavour}) {
+		state.SendReply(message.ChannelID, "You already voted on that appeal")
+		return
+	}
+	state.SendReply(message.ChannelID, fmt.Sprintf("Vote on appeal %v counted", appeal.ID))
+}
+
+func listAppeals(channelID, guildID string) {
+	open := db.GetOpenAppeals(channelID)
+	if len(open) == 0 {
+		state.SendReply(channelID, "No appeals are open here")
+		return
+	}
+
+	location := Location(guildID, "")
+	reply := "Open appeals:\n```\n"
+	for _, v := range open {
+		reply += fmt.Sprintf("%v: %v respec for %v on message %v, until %v\n", v.ID, -v.Respec, username(v.UserID), v.MessageID, LocalTime(v.Closes, location, "15:04"))
+	}
+	reply += "```"
+	state.SendReply(channelID, reply)
+}
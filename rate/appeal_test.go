@@ -0,0 +1,47 @@
+package rate
+
+import "testing"
+
+func TestParseMessageID(t *testing.T) {
+	cases := map[string]string{
+		"412345678901234567": "412345678901234567",
+		"https://discordapp.com/channels/1/2/412345678901234567":   "412345678901234567",
+		"<https://discordapp.com/channels/1/2/412345678901234567>": "412345678901234567",
+		"yes":                              "",
+		"https://discordapp.com/channels/": "",
+	}
+
+	for arg, want := range cases {
+		if got := parseMessageID(arg); got != want {
+			t.Errorf("%q: wanted %q, got %q", arg, want, got)
+		}
+	}
+}
+
+func TestAppealVotePassed(t *testing.T) {
+	cases := []struct {
+		yes, no, minimum int
+		want             bool
+	}{
+		{3, 0, 3, true},
+		{2, 0, 3, false},
+		{3, 3, 3, false},
+		{4, 3, 3, true},
+		{0, 0, 0, false},
+	}
+
+	for _, v := range cases {
+		if got := appealVotePassed(v.yes, v.no, v.minimum); got != v.want {
+			t.Errorf("%v to %v needing %v: wanted %v, got %v", v.yes, v.no, v.minimum, v.want, got)
+		}
+	}
+}
+
+func TestAppealPenalty(t *testing.T) {
+	if got := appealPenalty(3, 3, 5); got != 0 {
+		t.Errorf("At the limit: wanted 0, got %v", got)
+	}
+	if got := appealPenalty(4, 3, 5); got != 5 {
+		t.Errorf("Over the limit: wanted 5, got %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package rate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultProfile = "default"
+
+// how the rules rate messages in a kind of channel
+type ruleProfile struct {
+	name        string
+	description string
+	// percent each rule counts for, rules that aren't here count fully
+	weights map[string]int
+	// fewer words than this is a one word reply, more than maxWords is a wall of text
+	minWords int
+	maxWords int
+}
+
+var (
+	profiles = map[string]*ruleProfile{
+		defaultProfile: {name: defaultProfile, description: "Every rule counts the same", minWords: 2, maxWords: 30},
+		"chatty": {name: "chatty", description: "Short messages and double posts are fine",
+			weights: map[string]int{"lastpost": 0, "letters": 50, "time": 50}, minWords: 1, maxWords: 50},
+		"strict": {name: "strict", description: "For real discussion, say something worth reading",
+			weights: map[string]int{"letters": 150, "length": 150, "sentiment": 200, "spam": 200, "quotes": 200}, minWords: 4, maxWords: 150},
+		"media": {name: "media", description: "Pictures and links, nobody's here to read",
+			weights: map[string]int{"letters": 0, "length": 0, "sentiment": 50, "media": 200}, minWords: 0, maxWords: 30},
+	}
+	// channels' profile names, loaded when they're first needed
+	channelProfiles   map[string]string
+	channelProfileMux sync.Mutex
+)
+
+func init() {
+	channelProfiles = make(map[string]string)
+}
+
+// what a rule's respec is worth in this profile
+func (profile *ruleProfile) weigh(name string, respec int) int {
+	weight, ok := profile.weights[name]
+	if !ok {
+		return respec
+	}
+	return respec * weight / 100
+}
+
+// the profile a channel's messages are rated with
+func channelProfile(channelID string) *ruleProfile {
+	channelProfileMux.Lock()
+	defer channelProfileMux.Unlock()
+
+	name, ok := channelProfiles[channelID]
+	if !ok {
+		if channel, found := db.GetChannel(channelID); found {
+			name = channel.Profile
+		}
+		channelProfiles[channelID] = name
+	}
+
+	if profile, ok := profiles[name]; ok {
+		return profile
+	}
+	return profiles[defaultProfile]
+}
+
+func setChannelProfile(channelID, name string) {
+	channelProfileMux.Lock()
+	defer channelProfileMux.Unlock()
+
+	if name == defaultProfile {
+		name = ""
+	}
+	db.SetChannelProfile(channelID, name)
+	channelProfiles[channelID] = name
+}
+
+func describeProfiles() string {
+	var names []string
+	for k := range profiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	reply := "```\n"
+	for _, v := range names {
+		reply += fmt.Sprintf("%-8v - %v\n", v, profiles[v].description)
+	}
+	return reply + "```"
+}
+
+// ChannelCmd Show or change how this channel's messages are rated
+func ChannelCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 {
+		profile := channelProfile(message.ChannelID)
+		state.SendReply(message.ChannelID, fmt.Sprintf("This channel uses the %v profile: %v", profile.name, profile.description))
+		return
+	}
+
+	switch args[1] {
+	case "profile":
+		if len(args) < 3 {
+			state.SendReply(message.ChannelID, "Profiles:\n"+describeProfiles())
+			return
+		}
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+		name := strings.ToLower(args[2])
+		if _, ok := profiles[name]; !ok {
+			state.SendReply(message.ChannelID, fmt.Sprintf("There's no %v profile", name))
+			return
+		}
+		if _, ok := db.GetChannel(message.ChannelID); !ok {
+			state.SendReply(message.ChannelID, "I'm not rating this channel")
+			return
+		}
+		setChannelProfile(message.ChannelID, name)
+		state.SendReply(message.ChannelID, fmt.Sprintf("This channel uses the %v profile now", name))
+	default:
+		reply := "```\n"
+		reply += "'channel' - show this channel's profile\n"
+		reply += "'channel profile' - list the profiles\n"
+		reply += "'channel profile [name]' - rate this channel's messages with a profile (admins)\n"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+	}
+}
@@ -0,0 +1,48 @@
+package rate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestProfileWeigh(t *testing.T) {
+	profile := &ruleProfile{weights: map[string]int{"off": 0, "half": 50, "double": 200}}
+	cases := []struct {
+		name   string
+		respec int
+		want   int
+	}{
+		{"off", 5, 0},
+		{"half", 5, 2},
+		{"half", -4, -2},
+		{"double", -3, -6},
+		{"missing", 3, 3},
+	}
+
+	for _, v := range cases {
+		if got := profile.weigh(v.name, v.respec); got != v.want {
+			t.Errorf("%v %v: wanted %v, got %v", v.name, v.respec, v.want, got)
+		}
+	}
+}
+
+func TestProfileLength(t *testing.T) {
+	message := &discordgo.Message{}
+	short := parseContent("ok")
+	long := parseContent(strings.Repeat("word ", 40))
+
+	if got := respecLength(nil, message, short, profiles[defaultProfile]); got != -smallValue {
+		t.Errorf("One word by default: wanted %v, got %v", -smallValue, got)
+	}
+	if got := respecLength(nil, message, short, profiles["chatty"]); got != 0 {
+		t.Errorf("One word when chatty: wanted 0, got %v", got)
+	}
+	if got := respecLength(nil, message, long, profiles[defaultProfile]); got != -bigValue {
+		t.Errorf("Long message by default: wanted %v, got %v", -bigValue, got)
+	}
+	if got := respecLength(nil, message, long, profiles["strict"]); got != 0 {
+		t.Errorf("Long message when strict: wanted 0, got %v", got)
+	}
+}
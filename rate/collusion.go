@@ -0,0 +1,225 @@
+package rate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// how far back rewards are looked at
+	collusionWindow   = 7 * 24 * time.Hour
+	collusionInterval = time.Hour
+	// both users have to reward each other at least this many times
+	minMutualRewards = 10
+	// and on average this much of what they give has to go to each other
+	minMutualShare = 0.4
+)
+
+type collusionPair struct {
+	giver    string
+	receiver string
+}
+
+type collusionRing struct {
+	members []string
+	rewards int
+	share   float64
+}
+
+var (
+	// suspected pairs and rings by guild, rings only count what happens in one server
+	colluders    map[string]map[collusionPair]bool
+	rings        map[string][]collusionRing
+	collusionMux sync.RWMutex
+)
+
+func init() {
+	colluders = make(map[string]map[collusionPair]bool)
+	rings = make(map[string][]collusionRing)
+
+	config.Register("collusion.discount", "25", "percent of respec reactions between suspected colluders are still worth")
+}
+
+// look for colluders every so often
+func analyzeCollusion() {
+	for {
+		found := findGuildRings(db.GetInteractions(time.Now().Add(-collusionWindow)))
+
+		pairs := make(map[string]map[collusionPair]bool)
+		count := 0
+		for guildID, guildRings := range found {
+			pairs[guildID] = make(map[collusionPair]bool)
+			for _, ring := range guildRings {
+				for _, a := range ring.members {
+					for _, b := range ring.members {
+						if a != b {
+							pairs[guildID][collusionPair{a, b}] = true
+						}
+					}
+				}
+			}
+			count += len(guildRings)
+		}
+
+		collusionMux.Lock()
+		colluders = pairs
+		rings = found
+		collusionMux.Unlock()
+
+		logging.Log(fmt.Sprintf("found %v suspected collusion rings", count))
+		time.Sleep(collusionInterval)
+	}
+}
+
+// suspected colluders only get a part of what their reactions would be worth
+func collusionDiscount(guildID, giver, receiver string, respec int) int {
+	collusionMux.RLock()
+	suspected := colluders[guildID][collusionPair{giver, receiver}]
+	collusionMux.RUnlock()
+
+	if !suspected || respec <= 0 {
+		return respec
+	}
+	return discounted(respec, config.Int(guildID, "collusion.discount"))
+}
+
+// a percent of positive respec rounded up, so small reactions still count for something
+func discounted(respec, percent int) int {
+	return (respec*percent + 99) / 100
+}
+
+// the rings in each guild, only looking at what happened in that guild
+func findGuildRings(interactions []db.Interaction) map[string][]collusionRing {
+	byGuild := make(map[string][]db.Interaction)
+	for _, v := range interactions {
+		byGuild[v.GuildID] = append(byGuild[v.GuildID], v)
+	}
+
+	found := make(map[string][]collusionRing)
+	for guildID, v := range byGuild {
+		if guildRings := findRings(v); len(guildRings) > 0 {
+			found[guildID] = guildRings
+		}
+	}
+	return found
+}
+
+// find groups of users that mostly reward each other
+func findRings(interactions []db.Interaction) (found []collusionRing) {
+	given := make(map[collusionPair]int)
+	total := make(map[string]int)
+	for _, v := range interactions {
+		given[collusionPair{v.GiverID, v.ReceiverID}] += v.Count
+		total[v.GiverID] += v.Count
+	}
+
+	// users that reward each other far more than anyone else
+	neighbours := make(map[string]map[string]bool)
+	for k, ab := range given {
+		ba := given[collusionPair{k.receiver, k.giver}]
+		if ab < minMutualRewards || ba < minMutualRewards {
+			continue
+		}
+		share := (float64(ab)/float64(total[k.giver]) + float64(ba)/float64(total[k.receiver])) / 2
+		if share < minMutualShare {
+			continue
+		}
+		if neighbours[k.giver] == nil {
+			neighbours[k.giver] = make(map[string]bool)
+		}
+		neighbours[k.giver][k.receiver] = true
+	}
+
+	var cliques [][]string
+	var candidates []string
+	for k := range neighbours {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	bronKerbosch(nil, candidates, nil, neighbours, &cliques)
+
+	for _, members := range cliques {
+		ring := collusionRing{members: members}
+		var out int
+		for _, a := range members {
+			out += total[a]
+			for _, b := range members {
+				if a != b {
+					ring.rewards += given[collusionPair{a, b}]
+				}
+			}
+		}
+		ring.share = float64(ring.rewards) / float64(out)
+		found = append(found, ring)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].rewards > found[j].rewards })
+	return
+}
+
+// every maximal clique of at least two users
+func bronKerbosch(clique, candidates, excluded []string, neighbours map[string]map[string]bool, cliques *[][]string) {
+	if len(candidates) == 0 && len(excluded) == 0 {
+		if len(clique) > 1 {
+			members := append([]string(nil), clique...)
+			sort.Strings(members)
+			*cliques = append(*cliques, members)
+		}
+		return
+	}
+
+	for len(candidates) > 0 {
+		v := candidates[0]
+		var nextCandidates, nextExcluded []string
+		for _, u := range candidates {
+			if neighbours[v][u] {
+				nextCandidates = append(nextCandidates, u)
+			}
+		}
+		for _, u := range excluded {
+			if neighbours[v][u] {
+				nextExcluded = append(nextExcluded, u)
+			}
+		}
+		next := append(append([]string(nil), clique...), v)
+		bronKerbosch(next, nextCandidates, nextExcluded, neighbours, cliques)
+
+		candidates = candidates[1:]
+		excluded = append(excluded, v)
+	}
+}
+
+// CollusionCmd Show moderators the suspected rings in their server
+func CollusionCmd(message *discordgo.Message, args []string) {
+	if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+		state.SendReply(message.ChannelID, "You can't do that")
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	collusionMux.RLock()
+	defer collusionMux.RUnlock()
+
+	reply := fmt.Sprintf("Suspected rings in the last %v days, their reactions are worth %v%%:\n```\n", int(collusionWindow.Hours()/24), config.Int(guildID, "collusion.discount"))
+	for _, ring := range rings[guildID] {
+		reply += fmt.Sprintf("%v - %v rewards, %.0f%% of what they give\n", strings.Join(ring.members, ", "), ring.rewards, ring.share*100)
+	}
+	if len(rings[guildID]) == 0 {
+		reply += "Nobody, you're all honest\n"
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
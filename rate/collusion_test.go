@@ -0,0 +1,78 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+)
+
+func TestFindRings(t *testing.T) {
+	interactions := []db.Interaction{
+		// a, b and c only upvote each other
+		{GiverID: "a", ReceiverID: "b", Count: 20},
+		{GiverID: "b", ReceiverID: "a", Count: 15},
+		{GiverID: "a", ReceiverID: "c", Count: 12},
+		{GiverID: "c", ReceiverID: "a", Count: 18},
+		{GiverID: "b", ReceiverID: "c", Count: 11},
+		{GiverID: "c", ReceiverID: "b", Count: 14},
+		// d and e are a pair
+		{GiverID: "d", ReceiverID: "e", Count: 30},
+		{GiverID: "e", ReceiverID: "d", Count: 25},
+		// f and g react to each other a lot but to everyone else much more
+		{GiverID: "f", ReceiverID: "g", Count: 10},
+		{GiverID: "g", ReceiverID: "f", Count: 10},
+		{GiverID: "f", ReceiverID: "a", Count: 50},
+		{GiverID: "g", ReceiverID: "d", Count: 50},
+		// h upvotes i without anything back
+		{GiverID: "h", ReceiverID: "i", Count: 40},
+	}
+
+	found := findRings(interactions)
+	if len(found) != 2 {
+		t.Fatalf("Wanted 2 rings, got %+v", found)
+	}
+	if !reflect.DeepEqual(found[0].members, []string{"a", "b", "c"}) || found[0].rewards != 90 {
+		t.Errorf("Wrong first ring %+v", found[0])
+	}
+	if !reflect.DeepEqual(found[1].members, []string{"d", "e"}) || found[1].rewards != 55 {
+		t.Errorf("Wrong second ring %+v", found[1])
+	}
+}
+
+func TestDiscounted(t *testing.T) {
+	cases := []struct {
+		respec, percent, want int
+	}{
+		{reactionValue, 25, 1},
+		{1, 25, 1},
+		{8, 25, 2},
+		{10, 25, 3},
+		{reactionValue, 0, 0},
+		{reactionValue, 100, reactionValue},
+	}
+
+	for _, v := range cases {
+		if got := discounted(v.respec, v.percent); got != v.want {
+			t.Errorf("%v%% of %v: wanted %v, got %v", v.percent, v.respec, v.want, got)
+		}
+	}
+}
+
+func TestFindGuildRings(t *testing.T) {
+	interactions := []db.Interaction{
+		// a and b only upvote each other in one server
+		{GuildID: "1", GiverID: "a", ReceiverID: "b", Count: 20},
+		{GuildID: "1", GiverID: "b", ReceiverID: "a", Count: 15},
+		// c and d only get to the limit if both servers are added up
+		{GuildID: "1", GiverID: "c", ReceiverID: "d", Count: 6},
+		{GuildID: "1", GiverID: "d", ReceiverID: "c", Count: 6},
+		{GuildID: "2", GiverID: "c", ReceiverID: "d", Count: 6},
+		{GuildID: "2", GiverID: "d", ReceiverID: "c", Count: 6},
+	}
+
+	found := findGuildRings(interactions)
+	if len(found) != 1 || len(found["1"]) != 1 || !reflect.DeepEqual(found["1"][0].members, []string{"a", "b"}) {
+		t.Errorf("Wanted only a and b in guild 1, got %+v", found)
+	}
+}
@@ -0,0 +1,55 @@
+package rate
+
+import (
+	"sync"
+	"time"
+)
+
+// cooldownMatrix Remembers when each giver last credited each receiver
+type cooldownMatrix struct {
+	mux  sync.Mutex
+	last map[string]map[string]time.Time
+	// forget anything older than this
+	maxAge time.Duration
+	checks int
+}
+
+func newCooldownMatrix(maxAge time.Duration) *cooldownMatrix {
+	return &cooldownMatrix{last: make(map[string]map[string]time.Time), maxAge: maxAge}
+}
+
+// allow Check the giver hasn't credited the receiver within the cooldown and remember this one if they haven't
+func (m *cooldownMatrix) allow(giver, receiver string, now time.Time, cooldown time.Duration) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.checks++
+	if m.checks%1000 == 0 {
+		m.prune(now)
+	}
+
+	receivers, ok := m.last[giver]
+	if !ok {
+		receivers = make(map[string]time.Time)
+		m.last[giver] = receivers
+	}
+	if last, ok := receivers[receiver]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	receivers[receiver] = now
+	return true
+}
+
+// lock has to be held
+func (m *cooldownMatrix) prune(now time.Time) {
+	for giver, receivers := range m.last {
+		for receiver, last := range receivers {
+			if now.Sub(last) > m.maxAge {
+				delete(receivers, receiver)
+			}
+		}
+		if len(receivers) == 0 {
+			delete(m.last, giver)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package rate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldownMatrix(t *testing.T) {
+	m := newCooldownMatrix(time.Hour)
+	now := time.Now()
+	cooldown := 10 * time.Minute
+
+	if !m.allow("a", "b", now, cooldown) {
+		t.Error("First credit should be allowed")
+	}
+	if m.allow("a", "b", now.Add(5*time.Minute), cooldown) {
+		t.Error("Same pair inside the cooldown should be blocked")
+	}
+	if !m.allow("c", "b", now.Add(5*time.Minute), cooldown) {
+		t.Error("A different giver shouldn't be blocked")
+	}
+	if !m.allow("a", "d", now.Add(5*time.Minute), cooldown) {
+		t.Error("A different receiver shouldn't be blocked")
+	}
+	if !m.allow("a", "b", now.Add(cooldown), cooldown) {
+		t.Error("Same pair after the cooldown should be allowed")
+	}
+
+	m.prune(now.Add(2 * time.Hour))
+	if len(m.last) != 0 {
+		t.Errorf("Wanted everything pruned, got %v", m.last)
+	}
+}
+
+func TestCheckMentionLimit(t *testing.T) {
+	now := time.Now()
+	cooldown := 5 * time.Minute
+	cases := []struct {
+		lastPair time.Time
+		hasPair  bool
+		recent   int
+		cap      int
+		want     string
+	}{
+		{time.Time{}, false, 0, 5, ""},
+		{now.Add(-time.Minute), true, 0, 5, limitPair},
+		{now.Add(-10 * time.Minute), true, 0, 5, ""},
+		{time.Time{}, false, 5, 5, limitCap},
+		{time.Time{}, false, 50, 0, ""},
+		{now.Add(-time.Minute), true, 5, 5, limitPair},
+	}
+
+	for i, v := range cases {
+		if got := checkMentionLimit(v.lastPair, v.hasPair, v.recent, now, cooldown, v.cap); got != v.want {
+			t.Errorf("%v: wanted %q, got %q", i, v.want, got)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const decayInterval = time.Hour
+
+var (
+	// decay that hasn't added up to a whole respec yet
+	decayRemainder map[string]float64
+	decayMux       sync.Mutex
+)
+
+func init() {
+	decayRemainder = make(map[string]float64)
+
+	config.RegisterChoice("decay.mode", "linear", "how respec decays when a user goes quiet", "none", "linear", "halflife")
+	config.Register("decay.idle", "6", "hours without a message before respec starts to decay")
+	config.Register("decay.rate", "1", "respec lost every hour with linear decay")
+	config.Register("decay.halflife", "168", "hours for respec above the floor to halve with halflife decay")
+	config.Register("decay.floor", "0", "respec never decays below this")
+}
+
+// afk's lose respec while they're gone, not when they come back
+func decayRespec() {
+	ticker := time.NewTicker(decayInterval)
+	for range ticker.C {
+		decayUsers(time.Now())
+	}
+}
+
+func decayUsers(now time.Time) {
+	decayMux.Lock()
+	defer decayMux.Unlock()
+
+	guilds := make(map[string]bool)
+	for _, user := range score.All() {
+		if user.Respec <= 0 {
+			delete(decayRemainder, user.ID)
+			continue
+		}
+
+		// users decay by the rules of the last guild they talked in
+		message, ok := db.GetUserLastMessage(user.Username)
+		if !ok {
+			continue
+		}
+		channel, ok := db.GetChannel(message.ChannelID)
+		if !ok {
+			continue
+		}
+		guildID := channel.GuildID
+
+		if guildQuiet(guildID, now) {
+			continue
+		}
+		if now.Sub(message.Time).Hours() < float64(config.Int(guildID, "decay.idle")) {
+			delete(decayRemainder, user.ID)
+			continue
+		}
+
+		floor := config.Int(guildID, "decay.floor")
+		if user.Respec <= floor {
+			continue
+		}
+
+		amount := decayAmount(guildDecay(guildID), user.Respec-floor, decayInterval)
+		loss, left := decayLoss(decayRemainder[user.ID]+amount, user.Respec-floor)
+		decayRemainder[user.ID] = left
+		if loss < 1 {
+			continue
+		}
+
+		score.AddByID(user.ID, -loss)
+		db.AddHistory(db.History{UserID: user.ID, GuildID: guildID, Respec: -loss, Reason: reasonDecay, Time: now})
+		logging.Log(fmt.Sprintf("%v -%d respec from decay", user.Username, loss))
+		guilds[guildID] = true
+	}
+
+	for guildID := range guilds {
+		if state.IsActiveServer(guildID) {
+			scheduleReconcile(guildID)
+		}
+	}
+}
+
+// how a guild decays respec
+type decaySettings struct {
+	mode     string
+	rate     int
+	halfLife int
+}
+
+func guildDecay(guildID string) decaySettings {
+	return decaySettings{
+		mode:     config.String(guildID, "decay.mode"),
+		rate:     config.Int(guildID, "decay.rate"),
+		halfLife: config.Int(guildID, "decay.halflife"),
+	}
+}
+
+// how much of the respec above the floor goes away over the given time
+func decayAmount(settings decaySettings, aboveFloor int, elapsed time.Duration) float64 {
+	switch settings.mode {
+	case "linear":
+		return float64(settings.rate) * elapsed.Hours()
+	case "halflife":
+		if settings.halfLife <= 0 {
+			return 0
+		}
+		return float64(aboveFloor) * (1 - math.Pow(0.5, elapsed.Hours()/float64(settings.halfLife)))
+	}
+	return 0
+}
+
+// the whole respec lost, never going below the floor, and the part left over for next time
+func decayLoss(amount float64, aboveFloor int) (loss int, left float64) {
+	whole := math.Floor(amount)
+	loss = int(whole)
+	if loss > aboveFloor {
+		loss = aboveFloor
+	}
+	return loss, amount - whole
+}
+
+// HistoryCmd Show the most recent changes to a user's respec
+func HistoryCmd(message *discordgo.Message, args []string) {
+	user := message.Author
+	if len(message.Mentions) > 0 {
+		user = message.Mentions[0]
+	}
+
+	guildID, _ := state.GuildID(message.ChannelID)
+	location := Location(guildID, message.Author.ID)
+	history := db.GetUserHistory(user.ID, 15)
+	if len(history) == 0 {
+		state.SendReply(message.ChannelID, fmt.Sprintf("%v has no history", user.Username))
+		return
+	}
+
+	reply := fmt.Sprintf("%v's latest respec:\n```\n", user.Username)
+	for _, v := range history {
+		reply += fmt.Sprintf("%v %+4d %v\n", LocalTime(v.Time, location, "2006-01-02 15:04"), v.Respec, v.Reason)
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
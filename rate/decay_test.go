@@ -0,0 +1,75 @@
+package rate
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecayAmount(t *testing.T) {
+	cases := []struct {
+		settings   decaySettings
+		aboveFloor int
+		elapsed    time.Duration
+		want       float64
+	}{
+		{decaySettings{mode: "none", rate: 1, halfLife: 168}, 100, time.Hour, 0},
+		{decaySettings{mode: "linear", rate: 1}, 100, time.Hour, 1},
+		{decaySettings{mode: "linear", rate: 3}, 100, 2 * time.Hour, 6},
+		{decaySettings{mode: "halflife", halfLife: 1}, 100, time.Hour, 50},
+		{decaySettings{mode: "halflife", halfLife: 2}, 100, 4 * time.Hour, 75},
+		{decaySettings{mode: "halflife", halfLife: 0}, 100, time.Hour, 0},
+	}
+
+	for _, v := range cases {
+		if got := decayAmount(v.settings, v.aboveFloor, v.elapsed); math.Abs(got-v.want) > 1e-9 {
+			t.Errorf("%+v %v over %v: wanted %v, got %v", v.settings, v.aboveFloor, v.elapsed, v.want, got)
+		}
+	}
+}
+
+func TestDecayLoss(t *testing.T) {
+	cases := []struct {
+		amount     float64
+		aboveFloor int
+		loss       int
+		left       float64
+	}{
+		{0.5, 10, 0, 0.5},
+		{1.25, 10, 1, 0.25},
+		{3, 10, 3, 0},
+		{5.5, 2, 2, 0.5},
+	}
+
+	for _, v := range cases {
+		loss, left := decayLoss(v.amount, v.aboveFloor)
+		if loss != v.loss || math.Abs(left-v.left) > 1e-9 {
+			t.Errorf("%v above %v: wanted %v %v, got %v %v", v.amount, v.aboveFloor, v.loss, v.left, loss, left)
+		}
+	}
+}
+
+func TestQuietAt(t *testing.T) {
+	location, err := time.LoadLocation("America/Vancouver")
+	if err != nil {
+		t.Skip(err)
+	}
+	cases := []struct {
+		now        time.Time
+		start, end int
+		want       bool
+	}{
+		// 09:00 UTC is 01:00 in Vancouver
+		{time.Date(2018, time.January, 2, 9, 0, 0, 0, time.UTC), 0, 6, true},
+		{time.Date(2018, time.January, 2, 9, 0, 0, 0, time.UTC), 8, 12, false},
+		{time.Date(2018, time.January, 2, 7, 0, 0, 0, time.UTC), 22, 7, true},
+		{time.Date(2018, time.January, 2, 20, 0, 0, 0, time.UTC), 22, 7, false},
+		{time.Date(2018, time.January, 2, 9, 0, 0, 0, time.UTC), 0, 0, false},
+	}
+
+	for _, v := range cases {
+		if got := quietAt(v.now, location, v.start, v.end); got != v.want {
+			t.Errorf("%v in %v-%v: wanted %v, got %v", v.now, v.start, v.end, v.want, got)
+		}
+	}
+}
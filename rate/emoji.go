@@ -0,0 +1,153 @@
+package rate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const maxEmojiWeight = 10
+
+var (
+	guildEmojiWeights map[string]map[string]int
+	emojiMux          sync.Mutex
+	emojiPattern      = regexp.MustCompile(`^<a?:(\w+):(\d+)>$`)
+)
+
+func init() {
+	guildEmojiWeights = make(map[string]map[string]int)
+}
+
+// how much respec a reaction is worth in the guild
+func reactionWeight(guildID string, emoji discordgo.Emoji) int {
+	return emojiWeight(emojiWeightList(guildID), emoji)
+}
+
+func emojiWeight(weights map[string]int, emoji discordgo.Emoji) int {
+	if weight, ok := weights[db.EmojiKey(emoji)]; ok {
+		return weight
+	}
+	return reactionValue
+}
+
+func emojiWeightList(guildID string) map[string]int {
+	emojiMux.Lock()
+	defer emojiMux.Unlock()
+
+	weights, ok := guildEmojiWeights[guildID]
+	if !ok {
+		weights = make(map[string]int)
+		db.LoadEmojiWeights(guildID, &weights)
+		guildEmojiWeights[guildID] = weights
+	}
+	return weights
+}
+
+func resetEmojiWeights(guildID string) {
+	emojiMux.Lock()
+	delete(guildEmojiWeights, guildID)
+	emojiMux.Unlock()
+}
+
+// turn an emoji typed in a message into the key reactions are stored with
+func parseEmoji(arg string) discordgo.Emoji {
+	if match := emojiPattern.FindStringSubmatch(arg); match != nil {
+		return discordgo.Emoji{Name: match[1], ID: match[2]}
+	}
+	return discordgo.Emoji{Name: arg}
+}
+
+// show custom emoji the way discord renders them
+func displayEmoji(key string) string {
+	if strings.Contains(key, ":") {
+		return fmt.Sprintf("<:%v>", key)
+	}
+	return key
+}
+
+// EmojiCmd Handle the emoji command to manage how much reactions are worth
+func EmojiCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || args[1] == "help" {
+		reply := "```"
+		reply += "'emoji help' - display this message\n"
+		reply += "'emoji list' - display the emoji with their own weights\n"
+		reply += "'emoji set [emoji] [weight]' - set how much a reaction is worth, -10 to 10\n"
+		reply += "'emoji remove [emoji]' - put an emoji back to the default weight\n"
+		reply += fmt.Sprintf("(Every other reaction is worth %v, only server managers can change weights)", reactionValue)
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	cmd := strings.ToLower(args[1])
+	switch cmd {
+	case "list":
+		weights := emojiWeightList(guildID)
+		if len(weights) == 0 {
+			state.SendReply(message.ChannelID, fmt.Sprintf("Every reaction is worth %v", reactionValue))
+			return
+		}
+		var keys []string
+		for k := range weights {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var reply string
+		for _, k := range keys {
+			reply += fmt.Sprintf("%v %+d\n", displayEmoji(k), weights[k])
+		}
+		state.SendReply(message.ChannelID, reply)
+		return
+
+	case "set", "remove":
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+
+	default:
+		state.SendReply(message.ChannelID, "Not a valid emoji command, use `emoji help`")
+		return
+	}
+
+	if len(args) < 3 {
+		state.SendReply(message.ChannelID, fmt.Sprintf("Usage: `emoji %v [emoji]`", cmd))
+		return
+	}
+	key := db.EmojiKey(parseEmoji(args[2]))
+
+	if cmd == "remove" {
+		if db.RemoveEmojiWeight(guildID, key) {
+			state.SendReply(message.ChannelID, fmt.Sprintf("%v is worth %v again", displayEmoji(key), reactionValue))
+		} else {
+			state.SendReply(message.ChannelID, fmt.Sprintf("%v does not have its own weight", displayEmoji(key)))
+		}
+		resetEmojiWeights(guildID)
+		return
+	}
+
+	if len(args) < 4 {
+		state.SendReply(message.ChannelID, "Usage: `emoji set [emoji] [weight]`")
+		return
+	}
+	weight, err := strconv.Atoi(args[3])
+	if err != nil || weight < -maxEmojiWeight || weight > maxEmojiWeight {
+		state.SendReply(message.ChannelID, "Invalid weight")
+		return
+	}
+	db.SetEmojiWeight(guildID, key, weight)
+	resetEmojiWeights(guildID)
+	state.SendReply(message.ChannelID, fmt.Sprintf("%v is now worth %+d", displayEmoji(key), weight))
+}
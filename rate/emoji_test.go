@@ -0,0 +1,49 @@
+package rate
+
+import (
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEmojiKey(t *testing.T) {
+	cases := []struct {
+		emoji discordgo.Emoji
+		want  string
+	}{
+		{discordgo.Emoji{Name: "👍"}, "👍"},
+		{discordgo.Emoji{Name: "❤️"}, "❤"},
+		{discordgo.Emoji{Name: "pog", ID: "123"}, "pog:123"},
+		{parseEmoji("<:pog:123>"), "pog:123"},
+		{parseEmoji("<a:dance:456>"), "dance:456"},
+		{parseEmoji("❤️"), "❤"},
+	}
+
+	for _, v := range cases {
+		if got := db.EmojiKey(v.emoji); got != v.want {
+			t.Errorf("%v: wanted %q, got %q", v.emoji, v.want, got)
+		}
+	}
+}
+
+func TestEmojiWeight(t *testing.T) {
+	weights := map[string]int{"👎": -2, "pog:123": 5, "❤": 3, "meh:9": 0}
+	cases := []struct {
+		emoji discordgo.Emoji
+		want  int
+	}{
+		{discordgo.Emoji{Name: "👎"}, -2},
+		{discordgo.Emoji{Name: "pog", ID: "123"}, 5},
+		{discordgo.Emoji{Name: "pog", ID: "999"}, reactionValue},
+		{discordgo.Emoji{Name: "❤️"}, 3},
+		{discordgo.Emoji{Name: "meh", ID: "9"}, 0},
+		{discordgo.Emoji{Name: "🎉"}, reactionValue},
+	}
+
+	for _, v := range cases {
+		if got := emojiWeight(weights, v.emoji); got != v.want {
+			t.Errorf("%v: wanted %v, got %v", v.emoji.Name, v.want, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package rate
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// seeds get revealed and replaced this often
+const seedLifetime = 24 * time.Hour
+
+// RandSource Where the rolls for flipping respec come from
+type RandSource interface {
+	Float64() float64
+}
+
+// rolls from a secret seed that was committed to ahead of time, anyone can check the rolls once it's revealed
+type fairSource struct {
+	seed    []byte
+	seedID  uint64
+	nonce   uint64
+	started time.Time
+}
+
+var (
+	flipSource RandSource
+	flipMux    sync.Mutex
+)
+
+func init() {
+	flipSource = mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+}
+
+// SetRandSource Roll flips from the given source, so tests and replays can be deterministic
+func SetRandSource(source RandSource) {
+	flipMux.Lock()
+	flipSource = source
+	flipMux.Unlock()
+}
+
+func initFlips() {
+	// seeds from before a restart can't be continued so show them now
+	db.RevealFlipSeeds(time.Now())
+	SetRandSource(startFairSource())
+}
+
+// roll for a flip, the seed and nonce are only set for fair rolls
+func rollFlip() (roll float64, seedID, nonce uint64) {
+	flipMux.Lock()
+	defer flipMux.Unlock()
+
+	if fair, ok := flipSource.(*fairSource); ok {
+		if time.Since(fair.started) > seedLifetime {
+			db.RevealFlipSeeds(time.Now())
+			fair = startFairSource()
+			flipSource = fair
+		}
+		seedID, nonce = fair.seedID, fair.nonce
+	}
+	roll = flipSource.Float64()
+	return
+}
+
+// make a new secret seed and publish its hash
+func startFairSource() *fairSource {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+
+	commitment := sha256.Sum256(seed)
+	dbSeed := db.FlipSeed{Commitment: hex.EncodeToString(commitment[:]), Seed: hex.EncodeToString(seed), Start: time.Now()}
+	db.AddFlipSeed(&dbSeed)
+
+	return &fairSource{seed: seed, seedID: dbSeed.ID, started: dbSeed.Start}
+}
+
+func (f *fairSource) Float64() float64 {
+	roll := fairRoll(f.seed, f.nonce)
+	f.nonce++
+	return roll
+}
+
+// the first 53 bits of HMAC-SHA256(seed, nonce) as a number from 0 to 1
+func fairRoll(seed []byte, nonce uint64) float64 {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, nonce)
+
+	mac := hmac.New(sha256.New, seed)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// FlipsCmd Show a user the changes of theirs that got flipped
+func FlipsCmd(message *discordgo.Message, args []string) {
+	user := message.Author
+	if len(message.Mentions) > 0 {
+		user = message.Mentions[0]
+	}
+
+	guildID, _ := state.GuildID(message.ChannelID)
+	location := Location(guildID, message.Author.ID)
+	flips := db.GetUserFlips(user.ID, 10)
+	if len(flips) == 0 {
+		state.SendReply(message.ChannelID, fmt.Sprintf("%v has never been flipped", user.Username))
+		return
+	}
+
+	reply := fmt.Sprintf("%v's latest flips:\n```\n", user.Username)
+	for _, v := range flips {
+		reply += fmt.Sprintf("%v %+d became %+d (%.1f%% chance, rolled %.4f", LocalTime(v.Time, location, "2006-01-02 15:04"), v.Rating, -v.Rating, v.Chance*100, v.Roll)
+		if v.SeedID != 0 {
+			reply += fmt.Sprintf(", seed %v nonce %v", v.SeedID, v.Nonce)
+		}
+		reply += ")\n"
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
+
+// FairnessCmd Show the seed commitments so users can check their flips
+func FairnessCmd(message *discordgo.Message, args []string) {
+	reply := "Rolls are the first 53 bits of HMAC-SHA256(seed, nonce as 8 byte big endian) divided by 2^53. "
+	reply += "A change is flipped when the roll is below the chance. "
+	reply += "Each seed's SHA256 is posted before it's used and the seed is revealed once it's replaced.\n```\n"
+	guildID, _ := state.GuildID(message.ChannelID)
+	location := Location(guildID, message.Author.ID)
+	for _, v := range db.GetFlipSeeds(5) {
+		if v.Revealed {
+			reply += fmt.Sprintf("seed %v: %v\n  revealed %v\n", v.ID, v.Commitment, v.Seed)
+		} else {
+			reply += fmt.Sprintf("seed %v: %v\n  in use since %v\n", v.ID, v.Commitment, LocalTime(v.Start, location, "2006-01-02 15:04"))
+		}
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
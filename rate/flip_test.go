@@ -0,0 +1,61 @@
+package rate
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+type fixedSource []float64
+
+func (f *fixedSource) Float64() (roll float64) {
+	roll, *f = (*f)[0], (*f)[1:]
+	return
+}
+
+func TestFairRoll(t *testing.T) {
+	seed := sha256.Sum256([]byte("respec"))
+	source := &fairSource{seed: seed[:]}
+
+	for nonce := uint64(0); nonce < 100; nonce++ {
+		roll := source.Float64()
+		if roll < 0 || roll >= 1 {
+			t.Fatalf("Roll %v out of range", roll)
+		}
+		if again := fairRoll(seed[:], nonce); again != roll {
+			t.Fatalf("Roll %v could not be reproduced, got %v", roll, again)
+		}
+	}
+
+	other := sha256.Sum256([]byte("disrespec"))
+	if fairRoll(seed[:], 0) == fairRoll(other[:], 0) {
+		t.Errorf("Different seeds rolled the same")
+	}
+}
+
+func TestSetRandSource(t *testing.T) {
+	flipMux.Lock()
+	prev := flipSource
+	flipMux.Unlock()
+	defer SetRandSource(prev)
+
+	SetRandSource(&fixedSource{0.5, 0.25})
+
+	if roll, seedID, _ := rollFlip(); roll != 0.5 || seedID != 0 {
+		t.Errorf("Wanted 0.5 from the injected source, got %v seed %v", roll, seedID)
+	}
+	if roll, _, _ := rollFlip(); roll != 0.25 {
+		t.Errorf("Wanted 0.25 from the injected source, got %v", roll)
+	}
+}
+
+func TestFlipChance(t *testing.T) {
+	if chance := flipChance(1, 1000, 5); chance != 0.01 {
+		t.Errorf("Wanted minimum chance, got %v", chance)
+	}
+	if chance := flipChance(100, 150, 5); chance != 0.15 {
+		t.Errorf("Wanted maximum chance, got %v", chance)
+	}
+	if chance := flipChance(500, 1000, -5); chance != 0.01 {
+		t.Errorf("Wanted big users to rarely lose respec, got %v", chance)
+	}
+}
@@ -0,0 +1,157 @@
+package rate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// how much a guild lets users give
+type giveLimits struct {
+	daily      int
+	minBalance int
+	newDays    int
+	newLimit   int
+}
+
+var transferMux sync.Mutex
+
+func init() {
+	config.Register("give.daily", "50", "most respec a user can give away in a day")
+	config.Register("give.minbalance", "0", "respec a user has to keep after giving")
+	config.Register("give.newdays", "7", "accounts younger than this many days can't give and can only get a little")
+	config.Register("give.newlimit", "10", "most respec a new account can be given in a day")
+	config.Register("give.tip", "1", "respec given by a tip")
+}
+
+func guildGiveLimits(guildID string) giveLimits {
+	return giveLimits{
+		daily:      config.Int(guildID, "give.daily"),
+		minBalance: config.Int(guildID, "give.minbalance"),
+		newDays:    config.Int(guildID, "give.newdays"),
+		newLimit:   config.Int(guildID, "give.newlimit"),
+	}
+}
+
+// why a transfer isn't allowed, nil if it is
+func checkGive(limits giveLimits, amount, givenToday, receivedToday int, giverAge, receiverAge time.Duration) error {
+	newAge := time.Duration(limits.newDays) * day
+	if amount < 1 {
+		return fmt.Errorf("You have to give at least 1 respec")
+	}
+	if giverAge < newAge {
+		return fmt.Errorf("Your account is too new to give respec")
+	}
+	if givenToday+amount > limits.daily {
+		return fmt.Errorf("You can only give %v more respec today", nonNegative(limits.daily-givenToday))
+	}
+	if receiverAge < newAge && receivedToday+amount > limits.newLimit {
+		return fmt.Errorf("That account is new, it can only get %v more respec today", nonNegative(limits.newLimit-receivedToday))
+	}
+	return nil
+}
+
+func nonNegative(x int) int {
+	if x < 0 {
+		return 0
+	}
+	return x
+}
+
+// how long ago discord made the account
+func accountAge(userID string, now time.Time) time.Duration {
+	created, err := discordgo.SnowflakeTimestamp(userID)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(created)
+}
+
+// TransferRespec Move respec from one user to another, it doesn't get flipped and roles are kept up to date.
+// It doesn't go through AddRespec, a flip would make or destroy respec and both sides have to be saved together,
+// it reconciles roles the same way AddRespecReason does
+func TransferRespec(guildID string, from, to *discordgo.User, amount int, note string) error {
+	if from.ID == to.ID {
+		return fmt.Errorf("You can't give respec to yourself")
+	}
+	if to.Bot {
+		return fmt.Errorf("Bots don't need respec")
+	}
+
+	transferMux.Lock()
+	defer transferMux.Unlock()
+
+	now := time.Now()
+	limits := guildGiveLimits(guildID)
+	given, _ := db.GetTransferTotals(guildID, from.ID, now.Add(-day))
+	_, received := db.GetTransferTotals(guildID, to.ID, now.Add(-day))
+	if err := checkGive(limits, amount, given, received, accountAge(from.ID, now), accountAge(to.ID, now)); err != nil {
+		return err
+	}
+
+	transfer := db.Transfer{GuildID: guildID, FromID: from.ID, ToID: to.ID, Amount: amount, Note: note, Time: now}
+	err := score.Transfer(from.ID, to, amount, limits.minBalance, func(toIsNew bool) {
+		db.TransferRespec(&transfer, to.String(), toIsNew, reasonGive)
+	})
+	if err == score.ErrBalance {
+		return fmt.Errorf("You need to keep at least %v respec", limits.minBalance)
+	} else if err != nil {
+		return err
+	}
+
+	logging.Log(fmt.Sprintf("%v gave %v %v respec", from, to, amount))
+	scheduleReconcile(guildID)
+	return nil
+}
+
+// GiveCmd Give some of your respec to someone else
+func GiveCmd(message *discordgo.Message, args []string) {
+	if len(args) < 3 || len(message.Mentions) == 0 {
+		state.SendReply(message.ChannelID, "Usage: `give @user [amount] [note]`")
+		return
+	}
+	amount, err := strconv.Atoi(args[2])
+	if err != nil {
+		state.SendReply(message.ChannelID, "That's not an amount")
+		return
+	}
+	give(message, message.Mentions[0], amount, strings.Join(args[3:], " "))
+}
+
+// TipCmd Give someone a little respec
+func TipCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || len(message.Mentions) == 0 {
+		state.SendReply(message.ChannelID, "Usage: `tip @user [note]`")
+		return
+	}
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	give(message, message.Mentions[0], config.Int(guildID, "give.tip"), strings.Join(args[2:], " "))
+}
+
+func give(message *discordgo.Message, to *discordgo.User, amount int, note string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	if runes := []rune(note); len(runes) > 200 {
+		note = string(runes[:200])
+	}
+
+	if err := TransferRespec(guildID, message.Author, to, amount, note); err != nil {
+		state.SendReply(message.ChannelID, err.Error())
+		return
+	}
+	state.SendReply(message.ChannelID, fmt.Sprintf("%v gave %v %v respec", message.Author.Username, to.Username, amount))
+}
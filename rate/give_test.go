@@ -0,0 +1,36 @@
+package rate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckGive(t *testing.T) {
+	limits := giveLimits{daily: 50, minBalance: 0, newDays: 7, newLimit: 10}
+	old := 30 * day
+	young := 2 * day
+
+	cases := []struct {
+		name                    string
+		amount, given, received int
+		giverAge, receiverAge   time.Duration
+		ok                      bool
+	}{
+		{"fine", 20, 0, 0, old, old, true},
+		{"nothing", 0, 0, 0, old, old, false},
+		{"negative", -5, 0, 0, old, old, false},
+		{"up to the limit", 20, 30, 0, old, old, true},
+		{"over the limit", 21, 30, 0, old, old, false},
+		{"new giver", 1, 0, 0, young, old, false},
+		{"new receiver", 10, 0, 0, old, young, true},
+		{"new receiver over", 5, 0, 6, old, young, false},
+		{"old receiver got lots", 40, 0, 500, old, old, true},
+	}
+
+	for _, v := range cases {
+		err := checkGive(limits, v.amount, v.given, v.received, v.giverAge, v.receiverAge)
+		if (err == nil) != v.ok {
+			t.Errorf("%v: wanted ok %v, got %v", v.name, v.ok, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package rate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const leaderboardSize = 15
+
+var statWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// a user's place on the leaderboard before and after some time
+type mover struct {
+	userID string
+	from   int
+	to     int
+	respec int
+}
+
+// StatsCmd Show the leaderboard for all time or for some window
+func StatsCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || strings.ToLower(args[1]) == "all" {
+		leaders, losers := GetRespec()
+		var stats = "Leaderboard:\n```\n"
+		stats += leaders
+		stats += "```"
+		stats += "\nLosers:` "
+		stats += strings.Join(losers, ", ")
+		stats += " `"
+		if rank, ok := score.Rank(message.Author.ID); ok {
+			stats += fmt.Sprintf("\nYou're #%v of %v", rank, score.Count())
+		}
+		state.SendReply(message.ChannelID, stats)
+		return
+	}
+
+	cmd := strings.ToLower(args[1])
+	if cmd == "help" {
+		reply := "```"
+		reply += "'stats' - the all time leaderboard\n"
+		reply += "'stats day|week|month' - who gained the most respec in this server lately\n"
+		reply += "'stats since YYYY-MM-DD' - who gained the most respec in this server since a day\n"
+		reply += "'stats season' - this season's standings, the ones that start over\n"
+		reply += "'stats movers [day|week|month]' - who in this server climbed and fell the most, a week by default"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	switch cmd {
+	case "season":
+		state.SendReply(message.ChannelID, seasonBoard(guildID))
+
+	case "since":
+		if len(args) < 3 {
+			state.SendReply(message.ChannelID, "Usage: `stats since YYYY-MM-DD`")
+			return
+		}
+		since, err := time.ParseInLocation("2006-01-02", args[2], Location(guildID, ""))
+		if err != nil || since.After(time.Now()) {
+			state.SendReply(message.ChannelID, "That's not a day I know, use YYYY-MM-DD")
+			return
+		}
+		title := fmt.Sprintf("Since %v", since.Format("2006-01-02"))
+		state.SendReply(message.ChannelID, windowLeaderboard(title, db.GetRespecSince(guildID, since)))
+
+	case "movers":
+		window := "week"
+		if len(args) > 2 {
+			window = strings.ToLower(args[2])
+		}
+		length, ok := statWindows[window]
+		if !ok {
+			state.SendReply(message.ChannelID, "Movers can be for a day, week or month")
+			return
+		}
+		guild, err := state.Session.Guild(guildID)
+		if err != nil {
+			return
+		}
+		state.SendReply(message.ChannelID, moversBoard(window, guild, db.GetRespecSince(guildID, time.Now().Add(-length))))
+
+	default:
+		length, ok := statWindows[cmd]
+		if !ok {
+			state.SendReply(message.ChannelID, "Not a valid stats command, use `stats help`")
+			return
+		}
+		title := fmt.Sprintf("This %v", cmd)
+		state.SendReply(message.ChannelID, windowLeaderboard(title, db.GetRespecSince(guildID, time.Now().Add(-length))))
+	}
+}
+
+func username(userID string) string {
+	if e, ok := score.Lookup(userID); ok {
+		return e.Username
+	}
+	return userID
+}
+
+// standings have to be sorted most respec first
+func windowLeaderboard(title string, standings []db.Standing) string {
+	if len(standings) == 0 {
+		return fmt.Sprintf("%v: nobody's respec has changed", title)
+	}
+
+	var buf bytes.Buffer
+	w := new(tabwriter.Writer)
+	w.Init(&buf, 0, 0, 3, ' ', 0)
+	for i, v := range standings {
+		if i >= leaderboardSize {
+			break
+		}
+		fmt.Fprintf(w, "%v\t%+d\t\n", username(v.UserID), v.Respec)
+	}
+	w.Flush()
+
+	reply := fmt.Sprintf("%v:\n```\n%v```", title, buf.String())
+	if last := standings[len(standings)-1]; len(standings) > leaderboardSize && last.Respec < 0 {
+		reply += fmt.Sprintf("\nLost the most: `%v %+d`", username(last.UserID), last.Respec)
+	}
+	return reply
+}
+
+// where the guild's members moved on the leaderboard with what they did in the guild
+func moversBoard(window string, guild *discordgo.Guild, standings []db.Standing) string {
+	current := make(map[string]int)
+	for _, v := range guild.Members {
+		if !v.User.Bot {
+			current[v.User.ID] = score.GetByID(v.User.ID)
+		}
+	}
+	gained := make(map[string]int)
+	for _, v := range standings {
+		gained[v.UserID] = v.Respec
+	}
+
+	movers := rankMovers(current, gained)
+	if len(movers) == 0 {
+		return fmt.Sprintf("Nobody moved this %v", window)
+	}
+
+	var buf bytes.Buffer
+	w := new(tabwriter.Writer)
+	w.Init(&buf, 0, 0, 3, ' ', 0)
+	up, down := 0, 0
+	for _, v := range movers {
+		if v.to < v.from && up < 5 {
+			fmt.Fprintf(w, "%v\t#%v -> #%v\t%+d\t\n", username(v.userID), v.from, v.to, v.respec)
+			up++
+		}
+	}
+	for _, v := range movers {
+		if v.to > v.from && down < 5 {
+			fmt.Fprintf(w, "%v\t#%v -> #%v\t%+d\t\n", username(v.userID), v.from, v.to, v.respec)
+			down++
+		}
+	}
+	w.Flush()
+	return fmt.Sprintf("Biggest movers this %v:\n```\n%v```", window, buf.String())
+}
+
+// everyone whose rank changed, the biggest changes first
+func rankMovers(current, gained map[string]int) (movers []mover) {
+	before := make(map[string]int)
+	for k, v := range current {
+		before[k] = v - gained[k]
+	}
+	from := ranks(before)
+	to := ranks(current)
+
+	for k := range current {
+		if from[k] != to[k] {
+			movers = append(movers, mover{k, from[k], to[k], gained[k]})
+		}
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		a, b := abs(movers[i].from-movers[i].to), abs(movers[j].from-movers[j].to)
+		if a == b {
+			return movers[i].userID < movers[j].userID
+		}
+		return a > b
+	})
+	return
+}
+
+// everyone's place, starting at 1
+func ranks(respec map[string]int) map[string]int {
+	var users []rankedUser
+	for k, v := range respec {
+		users = append(users, rankedUser{k, v})
+	}
+	sortRanked(users)
+
+	rank := make(map[string]int)
+	for i, v := range users {
+		rank[v.id] = i + 1
+	}
+	return rank
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,26 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankMovers(t *testing.T) {
+	// before: a 50, b 40, c 30, d 20
+	current := map[string]int{"a": 50, "b": 35, "c": 30, "d": 60}
+	gained := map[string]int{"b": -5, "d": 40}
+
+	want := []mover{
+		{"d", 4, 1, 40},
+		{"a", 1, 2, 0},
+		{"b", 2, 3, -5},
+		{"c", 3, 4, 0},
+	}
+	if got := rankMovers(current, gained); !reflect.DeepEqual(got, want) {
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	if got := rankMovers(current, nil); len(got) != 0 {
+		t.Errorf("Wanted no movers, got %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package rate
+
+import (
+	"unicode"
+)
+
+// how many of each kind of letter a message has
+type letterCount struct {
+	// cased letters
+	caps, lower int64
+	// letters from scripts we know the vowels of
+	vowels, consonants int64
+	// letters from scripts without case, like CJK or Arabic
+	uncased int64
+	// anything that isn't a letter
+	other int64
+}
+
+// vowels for the scripts with vowel tables, looked up in lower case
+var (
+	latinVowels    = vowelTable("aeiouàáâãäåāăąæèéêëēĕėęěìíîïĩīĭįıòóôõöøōŏőœùúûüũūŭůűųýÿ")
+	cyrillicVowels = vowelTable("аеёиоуыэюяіїєў")
+	greekVowels    = vowelTable("αεηιουωάέήίόύώϊϋΐΰ")
+)
+
+func vowelTable(vowels string) map[rune]bool {
+	table := make(map[rune]bool)
+	for _, v := range vowels {
+		table[v] = true
+	}
+	return table
+}
+
+// the vowel table for a letter's script, nil if there isn't one
+func scriptVowels(c rune) map[rune]bool {
+	switch {
+	case unicode.Is(unicode.Latin, c):
+		return latinVowels
+	case unicode.Is(unicode.Cyrillic, c):
+		return cyrillicVowels
+	case unicode.Is(unicode.Greek, c):
+		return greekVowels
+	}
+	return nil
+}
+
+func countLetters(content string) (count letterCount) {
+	for _, c := range content {
+		if unicode.Is(unicode.Mn, c) {
+			// combining accents belong to the letter before them
+			continue
+		}
+		if !unicode.IsLetter(c) {
+			count.other++
+			continue
+		}
+
+		if unicode.IsUpper(c) || unicode.IsTitle(c) {
+			count.caps++
+		} else if unicode.IsLower(c) {
+			count.lower++
+		} else {
+			count.uncased++
+		}
+
+		if vowels := scriptVowels(c); vowels != nil {
+			if vowels[unicode.ToLower(c)] {
+				count.vowels++
+			} else {
+				count.consonants++
+			}
+		}
+	}
+	return
+}
+
+func (count letterCount) letters() int64 {
+	return count.caps + count.lower + count.uncased
+}
+
+// sentence endings in the scripts people use
+func endsSentence(c rune) bool {
+	switch c {
+	case '.', '?', '!', '。', '？', '！', '…', '؟', '।':
+		return true
+	}
+	return false
+}
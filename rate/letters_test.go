@@ -0,0 +1,48 @@
+package rate
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCountLetters(t *testing.T) {
+	file, err := os.Open("testdata/letters.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			t.Fatalf("Bad fixture line: %q", line)
+		}
+		var want letterCount
+		if _, err := fmt.Sscan(fields[0], &want.caps, &want.lower, &want.uncased, &want.vowels, &want.consonants, &want.other); err != nil {
+			t.Fatal(err)
+		}
+		if got := countLetters(fields[1]); got != want {
+			t.Errorf("%q: wanted %+v, got %+v", fields[1], want, got)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRespecLettersUncased(t *testing.T) {
+	message := &discordgo.Message{Content: "你好世界。"}
+	if got := respecLetters(&discordgo.User{}, message, parseContent(message.Content), profiles[defaultProfile]); got != 2 {
+		t.Errorf("Wanted 2, got %v", got)
+	}
+}
@@ -0,0 +1,218 @@
+package rate
+
+// AFINN style word list, every word is scored from -5 (very negative) to 5 (very positive)
+// guilds can add to or override it with the lexicon command
+var baseLexicon = map[string]int{
+	"abandon":      -2,
+	"abuse":        -3,
+	"accept":       1,
+	"adore":        3,
+	"afraid":       -2,
+	"agree":        1,
+	"amazing":      4,
+	"angry":        -3,
+	"annoying":     -2,
+	"appreciate":   2,
+	"awesome":      4,
+	"awful":        -3,
+	"bad":          -3,
+	"based":        2,
+	"beautiful":    3,
+	"best":         3,
+	"better":       2,
+	"bitch":        -5,
+	"blame":        -2,
+	"bored":        -2,
+	"boring":       -3,
+	"brilliant":    4,
+	"broken":       -1,
+	"bullshit":     -4,
+	"calm":         2,
+	"care":         2,
+	"clever":       2,
+	"cool":         1,
+	"crap":         -3,
+	"crappy":       -3,
+	"cringe":       -2,
+	"cry":          -1,
+	"cute":         2,
+	"damn":         -2,
+	"dead":         -3,
+	"delight":      3,
+	"delighted":    3,
+	"depressed":    -2,
+	"disappointed": -2,
+	"disgusting":   -3,
+	"dislike":      -2,
+	"dope":         3,
+	"dumb":         -3,
+	"easy":         1,
+	"enjoy":        2,
+	"epic":         3,
+	"excellent":    3,
+	"excited":      3,
+	"fail":         -2,
+	"failed":       -2,
+	"fair":         2,
+	"fantastic":    4,
+	"fine":         2,
+	"fuck":         -4,
+	"fucked":       -3,
+	"fucking":      -4,
+	"fun":          4,
+	"funny":        4,
+	"gg":           2,
+	"glad":         3,
+	"good":         3,
+	"gorgeous":     3,
+	"great":        3,
+	"gross":        -2,
+	"happy":        3,
+	"harm":         -2,
+	"hate":         -3,
+	"hated":        -3,
+	"helpful":      2,
+	"hilarious":    2,
+	"hope":         2,
+	"horrible":     -3,
+	"hurt":         -2,
+	"idiot":        -3,
+	"ignore":       -1,
+	"impressive":   3,
+	"interesting":  2,
+	"jealous":      -2,
+	"joke":         2,
+	"kill":         -3,
+	"kind":         2,
+	"lame":         -2,
+	"laugh":        1,
+	"lazy":         -1,
+	"liar":         -3,
+	"like":         2,
+	"lmao":         3,
+	"lol":          3,
+	"lonely":       -2,
+	"loser":        -3,
+	"love":         3,
+	"loved":        3,
+	"lovely":       3,
+	"lucky":        3,
+	"mad":          -3,
+	"mean":         -2,
+	"mess":         -2,
+	"miss":         -2,
+	"moron":        -3,
+	"nice":         3,
+	"nope":         -1,
+	"ok":           1,
+	"okay":         1,
+	"pathetic":     -2,
+	"perfect":      3,
+	"please":       1,
+	"pleased":      3,
+	"pog":          3,
+	"poggers":      3,
+	"poor":         -2,
+	"pretty":       1,
+	"proud":        2,
+	"rage":         -2,
+	"respect":      2,
+	"respec":       3,
+	"rip":          -1,
+	"rude":         -2,
+	"sad":          -2,
+	"scared":       -2,
+	"shit":         -4,
+	"shitty":       -3,
+	"sick":         -2,
+	"smart":        1,
+	"sorry":        -1,
+	"stupid":       -2,
+	"suck":         -3,
+	"sucks":        -3,
+	"super":        3,
+	"support":      2,
+	"sweet":        2,
+	"terrible":     -3,
+	"thank":        2,
+	"thanks":       2,
+	"thx":          2,
+	"trash":        -2,
+	"ugly":         -3,
+	"upset":        -2,
+	"useless":      -2,
+	"waste":        -1,
+	"weak":         -2,
+	"welcome":      2,
+	"win":          4,
+	"wonderful":    4,
+	"worse":        -3,
+	"worst":        -3,
+	"worthless":    -2,
+	"wow":          4,
+	"wrong":        -2,
+	"wtf":          -4,
+	"yay":          3,
+	"yes":          1,
+}
+
+// same as the words but for emoji, variation selectors are stripped before the lookup
+var baseEmoji = map[string]int{
+	"❤":  3,
+	"♥":  3,
+	"👍":  2,
+	"👎":  -2,
+	"👌":  2,
+	"👏":  2,
+	"🙏":  2,
+	"💯":  3,
+	"🔥":  2,
+	"🎉":  3,
+	"😀":  2,
+	"😁":  2,
+	"😂":  2,
+	"🤣":  2,
+	"😃":  2,
+	"😄":  2,
+	"😊":  2,
+	"😍":  3,
+	"🥰":  3,
+	"😎":  2,
+	"🙂":  1,
+	"😉":  1,
+	"😐":  -1,
+	"🙄":  -2,
+	"😒":  -2,
+	"😞":  -2,
+	"😢":  -2,
+	"😭":  -2,
+	"😠":  -3,
+	"😡":  -3,
+	"🤬":  -4,
+	"🤮":  -3,
+	"💩":  -2,
+	"🤡":  -2,
+	"🖕":  -4,
+	":)": 1,
+	":(": -1,
+	":D": 2,
+	"<3": 3,
+}
+
+// words that flip the score of the words following them
+var negators = map[string]bool{
+	"not":     true,
+	"no":      true,
+	"never":   true,
+	"neither": true,
+	"nor":     true,
+	"nothing": true,
+	"nobody":  true,
+	"without": true,
+	"hardly":  true,
+	"cant":    true,
+	"dont":    true,
+	"isnt":    true,
+	"wasnt":   true,
+	"aint":    true,
+}
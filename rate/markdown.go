@@ -0,0 +1,182 @@
+package rate
+
+import (
+	"regexp"
+	"strings"
+)
+
+// what part of a message a segment is
+type segmentKind int
+
+const (
+	segmentProse segmentKind = iota
+	segmentCode
+	segmentInlineCode
+	segmentQuote
+	segmentSpoiler
+	segmentEmoji
+)
+
+const (
+	codeFence = "```"
+	// shorter code than this on one line is just a word in backticks
+	minCodeLength = 10
+)
+
+// a piece of a message that's all the same kind
+type segment struct {
+	kind segmentKind
+	text string
+}
+
+// a message split up by discord markdown so rules can score each part differently
+type parsedContent struct {
+	raw      string
+	segments []segment
+}
+
+var inlinePattern = regexp.MustCompile("`([^`]+)`|\\|\\|(.+?)\\|\\||<a?:(\\w+):\\d+>")
+
+// parseContent Split a message into prose, code, quotes, spoilers and custom emoji
+func parseContent(content string) *parsedContent {
+	parsed := &parsedContent{raw: content}
+
+	for content != "" {
+		start := strings.Index(content, codeFence)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(content[start+len(codeFence):], codeFence)
+		if end < 0 {
+			break
+		}
+		end += start + len(codeFence)
+
+		parsed.addText(content[:start])
+		parsed.add(segmentCode, trimFenceLanguage(content[start+len(codeFence):end]))
+		content = content[end+len(codeFence):]
+	}
+	parsed.addText(content)
+
+	return parsed
+}
+
+// the first line of a code block is its language when there's more after it
+func trimFenceLanguage(code string) string {
+	if i := strings.Index(code, "\n"); i >= 0 && !strings.ContainsAny(code[:i], " \t") {
+		code = code[i+1:]
+	}
+	return strings.Trim(code, "\n")
+}
+
+// text outside code blocks, quotes are whole lines
+func (parsed *parsedContent) addText(text string) {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, ">>> "):
+			// everything after is quoted
+			parsed.add(segmentQuote, strings.TrimPrefix(strings.Join(lines[i:], ""), ">>> "))
+			return
+		case strings.HasPrefix(line, "> "):
+			parsed.add(segmentQuote, strings.TrimPrefix(line, "> "))
+		default:
+			parsed.addInline(line)
+		}
+	}
+}
+
+func (parsed *parsedContent) addInline(text string) {
+	last := 0
+	for _, match := range inlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		parsed.add(segmentProse, text[last:match[0]])
+		switch {
+		case match[2] >= 0:
+			parsed.add(segmentInlineCode, text[match[2]:match[3]])
+		case match[4] >= 0:
+			parsed.add(segmentSpoiler, text[match[4]:match[5]])
+		default:
+			parsed.add(segmentEmoji, text[match[6]:match[7]])
+		}
+		last = match[1]
+	}
+	parsed.add(segmentProse, text[last:])
+}
+
+// next to each other segments of the same kind are joined up
+func (parsed *parsedContent) add(kind segmentKind, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(parsed.segments); n > 0 && parsed.segments[n-1].kind == kind && kind != segmentCode {
+		parsed.segments[n-1].text += text
+		return
+	}
+	parsed.segments = append(parsed.segments, segment{kind, text})
+}
+
+// the text of every segment of some kinds
+func (parsed *parsedContent) text(kinds ...segmentKind) string {
+	var text []string
+	for _, v := range parsed.segments {
+		for _, kind := range kinds {
+			if v.kind == kind {
+				text = append(text, v.text)
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(text, ""))
+}
+
+// the user's own words, spoilers are still theirs
+func (parsed *parsedContent) prose() string {
+	return parsed.text(segmentProse, segmentSpoiler)
+}
+
+// the user's own words and any code too short to really be code
+func (parsed *parsedContent) words() string {
+	var text []string
+	for _, v := range parsed.segments {
+		switch v.kind {
+		case segmentProse, segmentSpoiler:
+			text = append(text, v.text)
+		case segmentCode, segmentInlineCode:
+			if !v.isCode() {
+				text = append(text, v.text)
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(text, ""))
+}
+
+// code worth reading, a few lines or something longer than a word
+func (v segment) isCode() bool {
+	lines := 0
+	for _, line := range strings.Split(v.text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines++
+		}
+	}
+	return lines > 1 || len([]rune(strings.TrimSpace(v.text))) >= minCodeLength
+}
+
+// any real code of some kinds
+func (parsed *parsedContent) hasCode(kinds ...segmentKind) bool {
+	for _, v := range parsed.segments {
+		for _, kind := range kinds {
+			if v.kind == kind && v.isCode() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (parsed *parsedContent) has(kind segmentKind) bool {
+	for _, v := range parsed.segments {
+		if v.kind == kind {
+			return true
+		}
+	}
+	return false
+}
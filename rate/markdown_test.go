@@ -0,0 +1,86 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseContent(t *testing.T) {
+	cases := []struct {
+		content string
+		want    []segment
+	}{
+		{"just words", []segment{{segmentProse, "just words"}}},
+		{"try this:\n```go\nfmt.Println(\"hi\")\n```\nworks for me", []segment{
+			{segmentProse, "try this:\n"},
+			{segmentCode, "fmt.Println(\"hi\")"},
+			{segmentProse, "\nworks for me"},
+		}},
+		{"```a b c```", []segment{{segmentCode, "a b c"}}},
+		{"```not closed", []segment{{segmentProse, "```not closed"}}},
+		{"use `go vet` first", []segment{
+			{segmentProse, "use "},
+			{segmentInlineCode, "go vet"},
+			{segmentProse, " first"},
+		}},
+		{"> you said this\nand I disagree", []segment{
+			{segmentQuote, "you said this\n"},
+			{segmentProse, "and I disagree"},
+		}},
+		{"ok\n>>> all\nof this", []segment{
+			{segmentProse, "ok\n"},
+			{segmentQuote, "all\nof this"},
+		}},
+		{"he dies ||at the end|| lol <:pog:1234> <a:dance:5678>", []segment{
+			{segmentProse, "he dies "},
+			{segmentSpoiler, "at the end"},
+			{segmentProse, " lol "},
+			{segmentEmoji, "pog"},
+			{segmentProse, " "},
+			{segmentEmoji, "dance"},
+		}},
+	}
+
+	for _, v := range cases {
+		if got := parseContent(v.content).segments; !reflect.DeepEqual(got, v.want) {
+			t.Errorf("%q: wanted %v, got %v", v.content, v.want, got)
+		}
+	}
+}
+
+func TestParsedProse(t *testing.T) {
+	parsed := parseContent("> quoted\nmine ||secret|| `code` <:pog:1>.")
+	if got, want := parsed.prose(), "mine secret  ."; got != want {
+		t.Errorf("Wanted %q, got %q", want, got)
+	}
+	if !parsed.has(segmentQuote) || parsed.has(segmentCode) {
+		t.Errorf("Wrong kinds in %v", parsed.segments)
+	}
+}
+
+func TestTrivialCode(t *testing.T) {
+	score := func(content string) int {
+		message := &discordgo.Message{Content: content}
+		parsed, profile := parseContent(content), profiles[defaultProfile]
+		return respecLetters(&discordgo.User{}, message, parsed, profile) +
+			respecLength(&discordgo.User{}, message, parsed, profile) +
+			respecCode(&discordgo.User{}, message, parsed, profile)
+	}
+
+	plain := score("k")
+	for _, v := range []string{"`k`", "```k```", "```\nk\n```", "```go\nk\n```"} {
+		if got := score(v); got > plain {
+			t.Errorf("%q scored %v, better than plain k at %v", v, got, plain)
+		}
+	}
+
+	block := "```go\nfmt.Println(\"hi\")\nreturn\n```"
+	if got := respecCode(&discordgo.User{}, &discordgo.Message{Content: block}, parseContent(block), profiles[defaultProfile]); got != minValue {
+		t.Errorf("Real code block got %v", got)
+	}
+	if !parseContent("try `go test ./...`").hasCode(segmentInlineCode) {
+		t.Errorf("Inline command wasn't code")
+	}
+}
@@ -0,0 +1,225 @@
+package rate
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	guildDomains map[string]map[string]bool
+	domainMux    sync.Mutex
+	linkPattern  = regexp.MustCompile(`https?://[^\s<>]+`)
+
+	imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true}
+	videoExtensions = map[string]bool{".mp4": true, ".webm": true, ".mov": true, ".mkv": true}
+)
+
+func init() {
+	guildDomains = make(map[string]map[string]bool)
+
+	config.Register("media.image", "2", "respec for each image posted")
+	config.Register("media.video", "2", "respec for each video posted")
+	config.Register("media.file", "0", "respec for each other file posted")
+	config.Register("media.embed", "1", "respec for each embed on a message")
+	config.Register("media.caption", "1", "respec for captioning the media you post")
+	config.Register("media.max", "5", "most respec a single message can get from media and links")
+	config.Register("link.allowed", "1", "respec for each link to an allowed domain")
+	config.Register("link.denied", "-5", "respec for each link to a denied domain")
+	config.Register("link.other", "0", "respec for each link to any other domain")
+}
+
+// how much a guild gives for media and links
+type mediaValues struct {
+	image, video, file, embed, caption, max int
+	allowed, denied, other                  int
+}
+
+func guildMediaValues(guildID string) mediaValues {
+	return mediaValues{
+		image:   config.Int(guildID, "media.image"),
+		video:   config.Int(guildID, "media.video"),
+		file:    config.Int(guildID, "media.file"),
+		embed:   config.Int(guildID, "media.embed"),
+		caption: config.Int(guildID, "media.caption"),
+		max:     config.Int(guildID, "media.max"),
+		allowed: config.Int(guildID, "link.allowed"),
+		denied:  config.Int(guildID, "link.denied"),
+		other:   config.Int(guildID, "link.other"),
+	}
+}
+
+// pictures are worth a thousand words
+func respecMedia(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	return scoreMedia(guildMediaValues(guildID), guildDomainList(guildID), message)
+}
+
+func scoreMedia(values mediaValues, domains map[string]bool, message *discordgo.Message) (respec int) {
+	media := 0
+	for _, v := range message.Attachments {
+		ext := strings.ToLower(path.Ext(v.Filename))
+		if imageExtensions[ext] {
+			respec += values.image
+		} else if videoExtensions[ext] {
+			respec += values.video
+		} else {
+			respec += values.file
+		}
+		media++
+	}
+	for range message.Embeds {
+		respec += values.embed
+		media++
+	}
+
+	for _, link := range linkPattern.FindAllString(message.Content, -1) {
+		switch allowed, listed := linkAllowed(domains, link); {
+		case !listed:
+			respec += values.other
+		case allowed:
+			respec += values.allowed
+		default:
+			respec += values.denied
+		}
+	}
+
+	if media > 0 && strings.TrimSpace(linkPattern.ReplaceAllString(message.Content, "")) != "" {
+		respec += values.caption
+	}
+
+	if respec > values.max {
+		respec = values.max
+	}
+	return
+}
+
+// if the message has something to look at besides the text
+func hasMedia(message *discordgo.Message) bool {
+	return len(message.Attachments) > 0 || len(message.Embeds) > 0
+}
+
+// check the link's domain and every parent domain against the guild's lists
+func linkAllowed(domains map[string]bool, link string) (allowed, listed bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false, false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for host != "" {
+		if allowed, listed = domains[host]; listed {
+			return
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false, false
+}
+
+func guildDomainList(guildID string) map[string]bool {
+	domainMux.Lock()
+	defer domainMux.Unlock()
+
+	domains, ok := guildDomains[guildID]
+	if !ok {
+		domains = make(map[string]bool)
+		db.LoadGuildDomains(guildID, &domains)
+		guildDomains[guildID] = domains
+	}
+	return domains
+}
+
+func resetGuildDomains(guildID string) {
+	domainMux.Lock()
+	delete(guildDomains, guildID)
+	domainMux.Unlock()
+}
+
+// DomainCmd Handle the domain command to manage the guild's allowed and denied link domains
+func DomainCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || args[1] == "help" {
+		reply := "```"
+		reply += "'domain help' - display this message\n"
+		reply += "'domain list' - display the allowed and denied domains\n"
+		reply += "'domain allow [domain]' - reward links to a domain\n"
+		reply += "'domain deny [domain]' - punish links to a domain\n"
+		reply += "'domain remove [domain]' - take a domain off the lists\n"
+		reply += "(Subdomains are included, only server managers can change the lists)"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	cmd := strings.ToLower(args[1])
+	if cmd == "list" {
+		var allowed, denied []string
+		for k, v := range guildDomainList(guildID) {
+			if v {
+				allowed = append(allowed, k)
+			} else {
+				denied = append(denied, k)
+			}
+		}
+		sort.Strings(allowed)
+		sort.Strings(denied)
+		reply := fmt.Sprintf("Allowed:` %v `\nDenied:` %v `", strings.Join(allowed, ", "), strings.Join(denied, ", "))
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	if cmd != "allow" && cmd != "deny" && cmd != "remove" {
+		state.SendReply(message.ChannelID, "Not a valid domain command, use `domain help`")
+		return
+	}
+	if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+		state.SendReply(message.ChannelID, "You can't do that")
+		return
+	}
+	if len(args) < 3 {
+		state.SendReply(message.ChannelID, fmt.Sprintf("Usage: `domain %v [domain]`", cmd))
+		return
+	}
+
+	domain := strings.ToLower(args[2])
+	if u, err := url.Parse(domain); err == nil && u.Hostname() != "" {
+		domain = strings.ToLower(u.Hostname())
+	}
+	domain = strings.TrimPrefix(domain, "www.")
+
+	switch cmd {
+	case "allow":
+		db.SetGuildDomain(guildID, domain, true)
+		state.SendReply(message.ChannelID, fmt.Sprintf("Links to `%v` are allowed", domain))
+	case "deny":
+		db.SetGuildDomain(guildID, domain, false)
+		state.SendReply(message.ChannelID, fmt.Sprintf("Links to `%v` are denied", domain))
+	case "remove":
+		if !db.RemoveGuildDomain(guildID, domain) {
+			state.SendReply(message.ChannelID, fmt.Sprintf("`%v` is not on a list", domain))
+			return
+		}
+		state.SendReply(message.ChannelID, fmt.Sprintf("Removed `%v`", domain))
+	}
+	resetGuildDomains(guildID)
+}
@@ -0,0 +1,67 @@
+package rate
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLinkAllowed(t *testing.T) {
+	domains := map[string]bool{"youtube.com": true, "spam.net": false, "ok.spam.net": true}
+	cases := []struct {
+		link            string
+		allowed, listed bool
+	}{
+		{"https://youtube.com/watch?v=1", true, true},
+		{"https://www.youtube.com/watch?v=1", true, true},
+		{"https://m.YouTube.com/watch", true, true},
+		{"http://spam.net", false, true},
+		{"http://deep.sub.spam.net/x", false, true},
+		{"http://ok.spam.net/x", true, true},
+		{"https://example.com", false, false},
+		{"https://notyoutube.com", false, false},
+	}
+
+	for _, v := range cases {
+		allowed, listed := linkAllowed(domains, v.link)
+		if allowed != v.allowed || listed != v.listed {
+			t.Errorf("%v: wanted %v %v, got %v %v", v.link, v.allowed, v.listed, allowed, listed)
+		}
+	}
+}
+
+func TestScoreMedia(t *testing.T) {
+	values := mediaValues{image: 2, video: 3, file: 0, embed: 1, caption: 1, max: 5, allowed: 1, denied: -5, other: 0}
+	domains := map[string]bool{"youtube.com": true, "spam.net": false}
+	files := func(names ...string) []*discordgo.MessageAttachment {
+		var attachments []*discordgo.MessageAttachment
+		for _, v := range names {
+			attachments = append(attachments, &discordgo.MessageAttachment{Filename: v})
+		}
+		return attachments
+	}
+	cases := []struct {
+		name    string
+		message *discordgo.Message
+		want    int
+	}{
+		{"nothing", &discordgo.Message{Content: "hi"}, 0},
+		{"image", &discordgo.Message{Attachments: files("cat.PNG")}, 2},
+		{"video", &discordgo.Message{Attachments: files("clip.mp4")}, 3},
+		{"file", &discordgo.Message{Attachments: files("notes.txt")}, 0},
+		{"embed", &discordgo.Message{Embeds: []*discordgo.MessageEmbed{{}}}, 1},
+		{"caption", &discordgo.Message{Content: "look at this", Attachments: files("cat.jpg")}, 3},
+		{"link is no caption", &discordgo.Message{Content: "https://example.com", Attachments: files("cat.jpg")}, 2},
+		{"allowed link", &discordgo.Message{Content: "https://youtube.com/watch"}, 1},
+		{"denied link", &discordgo.Message{Content: "https://spam.net"}, -5},
+		{"other link", &discordgo.Message{Content: "https://example.com"}, 0},
+		{"capped", &discordgo.Message{Content: "wow", Attachments: files("a.png", "b.png", "c.png")}, 5},
+		{"denied not capped", &discordgo.Message{Content: "https://spam.net https://spam.net"}, -10},
+	}
+
+	for _, v := range cases {
+		if got := scoreMedia(values, domains, v.message); got != v.want {
+			t.Errorf("%v: wanted %v, got %v", v.name, v.want, got)
+		}
+	}
+}
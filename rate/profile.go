@@ -0,0 +1,52 @@
+package rate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jaggernaut555/respecbot/achievement"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ProfileCmd Show a user's respec, rank and achievements
+func ProfileCmd(message *discordgo.Message, args []string) {
+	user := message.Author
+	if len(message.Mentions) > 0 {
+		user = message.Mentions[0]
+	}
+
+	reply := fmt.Sprintf("%v\n```\n", user.Username)
+	if rank, ok := score.Rank(user.ID); ok {
+		reply += fmt.Sprintf("Respec: %v (#%v of %v)\n", score.GetByID(user.ID), rank, score.Count())
+	} else {
+		reply += "Respec: none yet\n"
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err == nil {
+		if title := activeTitle(guildID, user.ID); title != "" {
+			reply += fmt.Sprintf("Title: %v\n", title)
+		}
+		streak := db.GetStreak(user.ID, guildID)
+		reply += fmt.Sprintf("Streak: %v days (best %v)\n", currentStreak(streak, guildID), streak.Best)
+	}
+
+	location := Location(guildID, message.Author.ID)
+	if up, down := db.GetVoteTotals(user.ID); up+down > 0 {
+		reply += fmt.Sprintf("Votes: %v ++, %v --\n", up, down)
+		for _, v := range describeVotes(user.ID, location) {
+			reply += "  " + v + "\n"
+		}
+	}
+
+	unlocked := achievement.Unlocked(user.ID, location)
+	reply += fmt.Sprintf("Achievements: %v/%v\n", len(unlocked), achievement.Count())
+	if len(unlocked) > 0 {
+		reply += "  " + strings.Join(unlocked, "\n  ") + "\n"
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
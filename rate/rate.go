@@ -4,25 +4,19 @@ import (
 	"bytes"
 	"fmt"
 	"math"
-	"math/rand"
-	"reflect"
 	"sort"
 	"text/tabwriter"
 	"time"
 
+	"github.com/Jaggernaut555/respecbot/achievement"
+	"github.com/Jaggernaut555/respecbot/config"
 	"github.com/Jaggernaut555/respecbot/db"
 	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
 	"github.com/Jaggernaut555/respecbot/state"
 	"github.com/bwmarrin/discordgo"
 )
 
-type pair struct {
-	Key   string
-	Value int
-}
-
-type pairList []pair
-
 const (
 	correctUsageValue = 2
 	reactionValue     = 2
@@ -30,41 +24,47 @@ const (
 	chatLimiter       = 111
 )
 
+// why respec changed, kept in each user's history
 const (
-	badChange  = iota
-	noChange   = iota
-	goodChange = iota
+	reasonOther    = "other"
+	reasonMessage  = "message"
+	reasonMention  = "mention"
+	reasonReaction = "reaction"
+	reasonDecay    = "decay"
+	reasonStreak   = "streak"
+	reasonGive     = "give"
+	reasonShop     = "shop"
+	reasonReply    = "reply"
+	reasonAppeal   = "appeal"
+	reasonVote     = "vote"
 )
 
+// why a mention didn't give respec, kept with the mention
 const (
-	rulingClassRoleName = "Ruling Class"
-	topUserRoleName     = "Supreme Ruler"
-	losersRoleNAme      = "Losers"
+	limitSelf = "self"
+	limitPair = "pair"
+	limitCap  = "cap"
 )
 
-var (
-	totalRespec       int
-	supremeRuler      string
-	rulingClass       map[string]bool
-	loserRoleID       map[string]string
-	rulerRoleID       map[string]string
-	rulingClassRoleID map[string]string
-)
+func init() {
+	config.Register("mention.cooldown", "5", "minutes before the same user mentioning someone gives them respec again")
+	config.Register("mention.cap", "5", "most mentions that give someone respec in the cap window, 0 for no cap")
+	config.Register("mention.capwindow", "60", "minutes the mention cap is counted over")
+}
 
 func InitRatings() {
-	userRatings := make(map[string]int)
-	rulingClass = make(map[string]bool)
-	loserRoleID = make(map[string]string)
-	rulerRoleID = make(map[string]string)
-	rulingClassRoleID = make(map[string]string)
-
-	rand.Seed(time.Now().Unix())
+	initFlips()
 
-	db.LoadRespec(&userRatings)
+	score.Load()
 
-	logging.Log(fmt.Sprintf("loaded %v ratings", len(userRatings)))
+	logging.Log(fmt.Sprintf("loaded %v ratings", score.Count()))
 
-	totalRespec = db.GetTotalRespec()
+	go analyzeCollusion()
+	go decayRespec()
+	go runSeasons()
+	go warnStreaks()
+	go expirePurchases()
+	go resolveAppeals()
 }
 
 func InitChannel(channelID string) (err error) {
@@ -74,216 +74,55 @@ func InitChannel(channelID string) (err error) {
 	}
 
 	db.AddChannel(channel, true)
-	state.Channels[channel.ID] = true
-	state.Servers[channel.GuildID] = true
-	if err = initLosers(channel.GuildID); err != nil {
-		return err
-	}
-	err = initTopUsers(channel.GuildID)
-	return err
-}
-
-func initLosers(guildID string) (err error) {
-	guild, err := state.Session.Guild(guildID)
-	if err != nil {
-		return err
-	}
-
-	loserRoleID[guildID] = getRoleID(guildID, losersRoleNAme)
-
-	for _, v := range guild.Members {
-		if v.User.Bot {
-			continue
-		}
-		if db.GetUserRespec(v.User) < 0 {
-			isALoser(guildID, v.User)
-		} else {
-			isNotALoser(guildID, v.User)
-		}
-	}
-	return nil
-}
-
-func initTopUsers(guildID string) (err error) {
-	guild, err := state.Session.Guild(guildID)
-	if err != nil {
-		return err
-	}
-
-	supremeID := getRoleID(guildID, topUserRoleName)
-	if supremeID != "" {
-		rulerRoleID[guildID] = supremeID
-
-		userID := db.GetTopUser()
-
-		for _, v := range guild.Members {
-			if v.User.Bot {
-				continue
-			}
-			if v.User.ID == userID {
-				continue
-			}
-			state.Session.GuildMemberRoleRemove(guildID, v.User.ID, supremeID)
-		}
-
-		if userID != "" {
-			err = state.Session.GuildMemberRoleAdd(guildID, userID, supremeID)
-			if err == nil {
-				supremeRuler = userID
-			}
-		}
-	}
-
-	return initRulingClass(guildID)
-}
-
-func initRulingClass(guildID string) (err error) {
-	rulingID := getRoleID(guildID, rulingClassRoleName)
-
-	if rulingID != "" {
-		rulingClassRoleID[guildID] = rulingID
-
-		return checkRulingClass(guildID)
-	}
-	return nil
+	state.SetChannel(channel, true)
+	return reconcileNow(channel.GuildID)
 }
 
-func checkTopUser(guildID string, user *discordgo.User) {
-	roleID, ok := rulerRoleID[guildID]
-	if !ok && roleID == "" {
-		roleID = getRoleID(guildID, topUserRoleName)
-		if roleID == "" {
-			return
-		}
-		rulerRoleID[guildID] = roleID
-	}
-
-	if db.UserIsTop(user) && !ok {
-		state.Session.GuildMemberRoleAdd(guildID, user.ID, roleID)
-		supremeRuler = user.ID
-	} else if db.UserIsTop(user) && ok && supremeRuler != user.ID {
-		state.Session.GuildMemberRoleRemove(guildID, supremeRuler, roleID)
-		state.Session.GuildMemberRoleAdd(guildID, user.ID, roleID)
-		supremeRuler = user.ID
-	} else if !db.UserIsTop(user) && ok && supremeRuler == user.ID {
-		state.Session.GuildMemberRoleRemove(guildID, user.ID, roleID)
-		newRuler := db.GetTopUser()
-		err := state.Session.GuildMemberRoleAdd(guildID, newRuler, roleID)
-		if err == nil {
-			supremeRuler = newRuler
-		}
-	}
-}
-
-func checkRulingClass(guildID string) (err error) {
-	guild, err := state.Session.Guild(guildID)
-	roleID, ok := rulingClassRoleID[guildID]
-	if err != nil {
-		return err
-	} else if !ok && roleID == "" {
-		roleID = getRoleID(guildID, rulingClassRoleName)
-		if roleID == "" {
-			return nil
-		}
-		rulingClassRoleID[guildID] = roleID
-	}
-
-	newRulingClass := make(map[string]bool)
-	db.GetRulingClass(&newRulingClass)
-
-	if reflect.DeepEqual(newRulingClass, rulingClass) {
-		return
-	}
-
-	for k, v := range newRulingClass {
-		rulingClass[k] = v
-	}
-
-	for _, v := range guild.Members {
-		if v.User.Bot {
-			continue
-		}
-		if rulingClass[v.User.ID] {
-			state.Session.GuildMemberRoleAdd(guildID, v.User.ID, roleID)
-		} else {
-			state.Session.GuildMemberRoleRemove(guildID, v.User.ID, roleID)
-		}
-	}
-	return nil
+func AddRespec(guildID string, user *discordgo.User, rating int) {
+	AddRespecReason(guildID, user, rating, reasonOther, "")
 }
 
-func isALoser(guildID string, user *discordgo.User) {
-	if roleID, ok := loserRoleID[guildID]; ok {
-		state.Session.GuildMemberRoleAdd(guildID, user.ID, roleID)
-	} else {
-		roleID = getRoleID(guildID, losersRoleNAme)
-		if roleID == "" {
-			return
-		}
-		loserRoleID[guildID] = roleID
-		state.Session.GuildMemberRoleAdd(guildID, user.ID, roleID)
-	}
+// AddRespecReason Give a user respec and remember why in their history, ref is the message or thing it came from
+func AddRespecReason(guildID string, user *discordgo.User, rating int, reason, ref string) {
+	addRespecHelp(guildID, user, rating, reason, ref)
+	scheduleReconcile(guildID)
 }
 
-func isNotALoser(guildID string, user *discordgo.User) {
-	if roleID, ok := loserRoleID[guildID]; ok {
-		state.Session.GuildMemberRoleRemove(guildID, user.ID, roleID)
-	} else {
-		roleID = getRoleID(guildID, losersRoleNAme)
-		if roleID == "" {
-			return
-		}
-		loserRoleID[guildID] = roleID
-		state.Session.GuildMemberRoleRemove(guildID, user.ID, roleID)
-	}
-}
+func addRespecHelp(guildID string, user *discordgo.User, rating int, reason, ref string) {
+	userRespec := score.Get(user)
+	totalRespec := score.Total()
+	newRespec := rating
 
-func getRoleID(guildID, roleName string) (roleID string) {
-	roles, _ := state.Session.GuildRoles(guildID)
-	var role *discordgo.Role
-	for _, v := range roles {
-		if v.Name == roleName {
-			role = v
-			break
-		}
-	}
-	if role == nil {
-		return ""
+	chance := flipChance(userRespec, totalRespec, rating)
+	if roll, seedID, nonce := rollFlip(); roll < chance && rating != 0 {
+		newRespec = -newRespec
+		db.AddFlip(db.Flip{UserID: user.ID, GuildID: guildID, Rating: rating, Chance: chance, Roll: roll,
+			UserRespec: userRespec, TotalRespec: totalRespec, SeedID: seedID, Nonce: nonce,
+			Reason: reason, Ref: ref, Time: time.Now()})
 	}
-	return role.ID
-}
-
-func AddRespec(guildID string, user *discordgo.User, rating int) {
-	change := addRespecHelp(user, rating)
 
-	if change == badChange {
-		isALoser(guildID, user)
-	} else if change == goodChange {
-		isNotALoser(guildID, user)
-	}
+	logging.Log(fmt.Sprintf("%v %+d respec", user, newRespec))
 
-	checkTopUser(guildID, user)
-	checkRulingClass(guildID)
+	score.Add(user, newRespec)
+	db.AddHistory(db.History{UserID: user.ID, GuildID: guildID, Respec: newRespec, Reason: reason, Ref: ref, Time: time.Now()})
 }
 
-func addRespecHelp(user *discordgo.User, rating int) int {
+// the chance a change gets flipped, the more respec you have the more likely
+func flipChance(userRespec, totalRespec, rating int) float64 {
 	// abs(userRating) / abs(totalRespec)
-	userRespec := db.GetUserRespec(user)
-	newRespec := rating
-
-	if totalRespec == 0 {
-		totalRespec = 1
-	}
 	if userRespec == 0 {
 		userRespec = 1
 	}
+	if totalRespec == 0 {
+		totalRespec = 1
+	}
 
 	temp := math.Abs(float64(userRespec)) * math.Log(1+math.Abs(float64(userRespec))) / math.Abs(float64(totalRespec)) * 0.65
 
 	if math.Abs(float64(userRespec)) > chatLimiter {
-		if userRespec > 0 && newRespec < 0 {
+		if userRespec > 0 && rating < 0 {
 			temp = 0.01
-		} else if userRespec < 0 && newRespec > 0 {
+		} else if userRespec < 0 && rating > 0 {
 			temp = 0.01
 		}
 	} else if temp > 0.15 {
@@ -291,29 +130,15 @@ func addRespecHelp(user *discordgo.User, rating int) int {
 	} else if temp < 0.01 {
 		temp = 0.01
 	}
-	if rand.Float64() < temp {
-		newRespec = -newRespec
-	}
-
-	totalRespec += newRespec
-	logging.Log(fmt.Sprintf("%v %+d respec", user, newRespec))
-
-	db.GainRespec(user, newRespec)
-
-	if userRespec >= 0 && userRespec+newRespec < 0 {
-		return badChange
-	} else if userRespec < 0 && userRespec+newRespec >= 0 {
-		return goodChange
-	}
-
-	return noChange
+	return temp
 }
 
 // evaluate messages
 func RespecMessage(message *discordgo.Message) {
 	author := message.Author
 	timeStamp, _ := message.Timestamp.Parse()
-	numRespec := applyRules(author, message)
+	content := parseContent(message.ContentWithMentionsReplaced())
+	numRespec := applyRules(author, message, content)
 
 	channel, err := state.Session.Channel(message.ChannelID)
 	if err != nil {
@@ -324,21 +149,31 @@ func RespecMessage(message *discordgo.Message) {
 		return
 	}
 
-	logging.Log(fmt.Sprintf("%v: %v", author, message.ContentWithMentionsReplaced()))
+	logging.Log(fmt.Sprintf("%v: %v", author, content.raw))
 
-	numRespec += respecMentions(guild.ID, author, message)
+	credited := respecVotes(guild.ID, author, message)
+	if repliedID := respecReply(guild.ID, author, message); repliedID != "" {
+		credited[repliedID] = true
+	}
+	numRespec += respecMentions(guild.ID, author, message, credited)
 
-	AddRespec(guild.ID, author, numRespec)
+	AddRespecReason(guild.ID, author, numRespec, reasonMessage, message.ID)
 
 	db.NewMessage(author, message, numRespec, timeStamp)
+	score.SetLastMessage(author.String(), timeStamp)
+	recordActivity(guild.ID, author, message.ChannelID, timeStamp)
+
+	if primeLetters(content.prose()) {
+		achievement.Fire(achievement.Event{Kind: achievement.PrimeMessage, UserID: author.ID, GuildID: guild.ID, ChannelID: message.ChannelID})
+	}
 }
 
 func messageExistsInDB(messageID string) bool {
 	return db.MessageExists(messageID)
 }
 
-// if someone talkin to you you aight
-func respecMentions(guildID string, author *discordgo.User, message *discordgo.Message) (respec int) {
+// if someone talkin to you you aight, replies and votes already got credit so their ping doesn't count again
+func respecMentions(guildID string, author *discordgo.User, message *discordgo.Message, credited map[string]bool) (respec int) {
 	usersList := message.Mentions
 	timeStamp, _ := message.Timestamp.Parse()
 
@@ -360,35 +195,43 @@ func respecMentions(guildID string, author *discordgo.User, message *discordgo.M
 	}
 
 	for _, v := range users {
-		if v.Bot {
+		if v.Bot || (credited[v.ID] && v.ID != author.ID) {
 			continue
 		}
 		if v.ID == author.ID {
 			logging.Log(fmt.Sprintf("%v mentioned self", author))
-			db.AddMention(author, v, message, -mentionValue, timeStamp)
+			db.AddMention(guildID, author, v, message, -mentionValue, limitSelf, timeStamp)
 			respec -= mentionValue
-		} else if !canMention(v, timeStamp) {
-			logging.Log(fmt.Sprintf("%v mentioned by %v too soon since last mention", v, author))
-			db.AddMention(author, v, message, 0, timeStamp)
+		} else if limit := mentionLimit(guildID, author, v, timeStamp); limit != "" {
+			logging.Log(fmt.Sprintf("%v mentioned by %v too soon (%v limit)", v, author, limit))
+			db.AddMention(guildID, author, v, message, 0, limit, timeStamp)
 		} else {
 			logging.Log(fmt.Sprintf("%v mentioned by %v", v, author))
-			AddRespec(guildID, v, mentionValue)
-			db.AddMention(author, v, message, mentionValue, timeStamp)
+			AddRespecReason(guildID, v, mentionValue, reasonMention, message.ID)
+			db.AddMention(guildID, author, v, message, mentionValue, "", timeStamp)
 		}
 	}
 
 	return
 }
 
-func canMention(user *discordgo.User, timeGiven time.Time) bool {
-	if oldTime, ok := db.GetUserLastMentionedTime(user.String()); ok {
-		timeDelta := timeGiven.Sub(oldTime)
-		if timeDelta.Minutes() < 5 {
-			return false
-		}
-		return true
+// which limit stops the giver's mention from giving the receiver respec, empty if none do
+func mentionLimit(guildID string, giver, receiver *discordgo.User, timeGiven time.Time) string {
+	lastPair, ok := db.GetLastMentionTime(guildID, giver.String(), receiver.String())
+	window := time.Duration(config.Int(guildID, "mention.capwindow")) * time.Minute
+	recent := db.CountMentionsSince(guildID, receiver.String(), timeGiven.Add(-window))
+	return checkMentionLimit(lastPair, ok, recent, timeGiven,
+		time.Duration(config.Int(guildID, "mention.cooldown"))*time.Minute, config.Int(guildID, "mention.cap"))
+}
+
+func checkMentionLimit(lastPair time.Time, hasPair bool, recent int, timeGiven time.Time, cooldown time.Duration, maxRecent int) string {
+	if hasPair && timeGiven.Sub(lastPair) < cooldown {
+		return limitPair
 	}
-	return true
+	if maxRecent > 0 && recent >= maxRecent {
+		return limitCap
+	}
+	return ""
 }
 
 func mentionRoleHelper(guild *discordgo.Guild, roleID string) (users []*discordgo.User) {
@@ -426,13 +269,15 @@ func RespecReactionAdd(reaction *discordgo.MessageReaction) {
 	channel, _ := state.Session.Channel(message.ChannelID)
 	guild, _ := state.Session.Guild(channel.GuildID)
 
+	weight := reactionWeight(guild.ID, reaction.Emoji)
+
 	if user.ID == author.ID {
-		AddRespec(guild.ID, author, -reactionValue)
-	} else if validReactionAdd(user.String(), author.String(), timeStamp) {
-		AddRespec(guild.ID, author, reactionValue)
+		AddRespecReason(guild.ID, author, -reactionValue, reasonReaction, message.ID)
+	} else if weight != 0 && validReactionAdd(user.String(), author.String(), timeStamp) {
+		AddRespecReason(guild.ID, author, collusionDiscount(guild.ID, user.String(), author.String(), weight), reasonReaction, message.ID)
 	}
 
-	logging.Log(fmt.Sprintf("%v got a reaction from %v", author, user))
+	logging.Log(fmt.Sprintf("%v got a %v reaction from %v", author, db.EmojiKey(reaction.Emoji), user))
 
 	db.ReactionAdd(user, reaction, timeStamp)
 }
@@ -459,16 +304,18 @@ func RespecReactionRemove(reaction *discordgo.MessageReaction) {
 	channel, _ := state.Session.Channel(message.ChannelID)
 	guild, _ := state.Session.Guild(channel.GuildID)
 
+	weight := reactionWeight(guild.ID, reaction.Emoji)
+
 	if author.ID == user.ID {
-		AddRespec(guild.ID, author, -reactionValue)
-	} else if validReactionRemove(user.String(), author.String(), timeStamp) {
-		AddRespec(guild.ID, author, -reactionValue)
+		AddRespecReason(guild.ID, author, -reactionValue, reasonReaction, message.ID)
+	} else if weight != 0 && validReactionRemove(user.String(), author.String(), timeStamp) {
+		AddRespecReason(guild.ID, author, -collusionDiscount(guild.ID, user.String(), author.String(), weight), reasonReaction, message.ID)
 	}
 
 	logging.Log(fmt.Sprintf("%v lost a reaction", author))
 
 	logging.Log(fmt.Sprintf("%v removed a reaction", user))
-	AddRespec(guild.ID, user, -reactionValue)
+	AddRespecReason(guild.ID, user, -reactionValue, reasonReaction, message.ID)
 	db.ReactionRemove(user, reaction, timeStamp)
 }
 
@@ -484,37 +331,19 @@ func validReactionRemove(GiverID, ReceiverID string, timeGiven time.Time) bool {
 	return true
 }
 
-// get all da users in list
-func getRatingsLists() (users pairList) {
-	temp := make(map[string]int)
-	db.LoadRespec(&temp)
-
-	for k, v := range temp {
-		users = append(users, pair{k, v})
-	}
-
-	return
-}
-
 // show 10 most RESPEC peep
 func GetRespec() (Leaderboard string, negativeUsers []string) {
 	var buf bytes.Buffer
 	negativeUsers = make([]string, 0)
-	users := getRatingsLists()
-
-	sort.Sort(sort.Reverse(users))
 
 	var padding = 3
 	w := new(tabwriter.Writer)
 	w.Init(&buf, 0, 0, padding, ' ', 0)
-	for k, v := range users {
-		if k > 15 {
-			break
-		}
-		if v.Value >= 0 {
-			fmt.Fprintf(w, "%v\t%v\t\n", v.Key, v.Value)
+	for _, v := range score.Top(16) {
+		if v.Respec >= 0 {
+			fmt.Fprintf(w, "%v\t%v\t\n", v.Username, v.Respec)
 		} else {
-			negativeUsers = append(negativeUsers, v.Key)
+			negativeUsers = append(negativeUsers, v.Username)
 		}
 	}
 	w.Flush()
@@ -522,7 +351,3 @@ func GetRespec() (Leaderboard string, negativeUsers []string) {
 	sort.Strings(negativeUsers)
 	return
 }
-
-func (p pairList) Len() int           { return len(p) }
-func (p pairList) Less(i, j int) bool { return p[i].Value < p[j].Value }
-func (p pairList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
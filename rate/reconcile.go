@@ -0,0 +1,157 @@
+package rate
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/achievement"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// wait for things to settle before fixing roles
+	reconcileDelay = 5 * time.Second
+	// but don't wait forever in a busy guild
+	reconcileMaxWait = 30 * time.Second
+	roleBatchSize    = 10
+	roleBatchPause   = 2 * time.Second
+	maxRoleRetries   = 3
+)
+
+type roleChange struct {
+	userID string
+	roleID string
+	tier   db.Tier
+	add    bool
+}
+
+type pendingReconcile struct {
+	timer *time.Timer
+	first time.Time
+}
+
+var (
+	pendingReconciles map[string]*pendingReconcile
+	guildReconcileMux map[string]*sync.Mutex
+	reconcileMux      sync.Mutex
+)
+
+func init() {
+	pendingReconciles = make(map[string]*pendingReconcile)
+	guildReconcileMux = make(map[string]*sync.Mutex)
+}
+
+// fix the guild's roles in the background once it's been quiet for a bit
+func scheduleReconcile(guildID string) {
+	reconcileMux.Lock()
+	defer reconcileMux.Unlock()
+
+	if p, ok := pendingReconciles[guildID]; ok {
+		if time.Since(p.first) < reconcileMaxWait {
+			p.timer.Reset(reconcileDelay)
+		}
+		return
+	}
+
+	pendingReconciles[guildID] = &pendingReconcile{
+		first: time.Now(),
+		timer: time.AfterFunc(reconcileDelay, func() {
+			reconcileMux.Lock()
+			delete(pendingReconciles, guildID)
+			reconcileMux.Unlock()
+
+			if err := reconcileNow(guildID); err != nil {
+				logging.Log(fmt.Sprintf("couldn't reconcile roles in %v: %v", guildID, err))
+			}
+		}),
+	}
+}
+
+// fix the guild's roles right away, only one at a time per guild
+func reconcileNow(guildID string) error {
+	reconcileMux.Lock()
+	mux, ok := guildReconcileMux[guildID]
+	if !ok {
+		mux = new(sync.Mutex)
+		guildReconcileMux[guildID] = mux
+	}
+	reconcileMux.Unlock()
+
+	mux.Lock()
+	defer mux.Unlock()
+	return syncTiers(guildID)
+}
+
+// only the roles members are missing or shouldn't have
+func diffTierRoles(guildMembers []*discordgo.Member, tiers []db.Tier, roleIDs []string, members []map[string]bool) (changes []roleChange) {
+	for _, v := range guildMembers {
+		if v.User.Bot {
+			continue
+		}
+		for i, roleID := range roleIDs {
+			has := memberHasRole(v, roleID)
+			if want := members[i][v.User.ID]; want && !has {
+				changes = append(changes, roleChange{v.User.ID, roleID, tiers[i], true})
+			} else if !want && has {
+				changes = append(changes, roleChange{v.User.ID, roleID, tiers[i], false})
+			}
+		}
+	}
+	return
+}
+
+// apply changes a few at a time so a big reshuffle doesn't hit the rate limit
+func applyRoleChanges(guildID string, changes []roleChange) {
+	if len(changes) > 0 {
+		logging.Log(fmt.Sprintf("applying %v role changes in %v", len(changes), guildID))
+	}
+
+	for i, change := range changes {
+		if i > 0 && i%roleBatchSize == 0 {
+			time.Sleep(roleBatchPause)
+		}
+
+		backoff := time.Second
+		for try := 0; ; try++ {
+			var err error
+			if change.add {
+				err = state.Session.GuildMemberRoleAdd(guildID, change.userID, change.roleID)
+			} else {
+				err = state.Session.GuildMemberRoleRemove(guildID, change.userID, change.roleID)
+			}
+			if err == nil {
+				tierChanged(guildID, change)
+				break
+			}
+			if try >= maxRoleRetries || !retryable(err) {
+				logging.Log(fmt.Sprintf("couldn't change role %v for %v: %v", change.roleID, change.userID, err))
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func tierChanged(guildID string, change roleChange) {
+	kind := achievement.TierLost
+	if change.add {
+		kind = achievement.TierGained
+	}
+	achievement.Fire(achievement.Event{Kind: kind, UserID: change.userID, GuildID: guildID, Name: change.tier.Name, Tier: change.tier.Kind, Count: change.tier.Value})
+}
+
+// rate limits and discord having a bad time are worth trying again
+func retryable(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	if !ok || restErr.Response == nil {
+		return true
+	}
+	code := restErr.Response.StatusCode
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,57 @@
+package rate
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDiffTierRoles(t *testing.T) {
+	guildMembers := []*discordgo.Member{
+		{User: &discordgo.User{ID: "a"}, Roles: []string{"r1"}},
+		{User: &discordgo.User{ID: "b"}, Roles: []string{"r1", "r2", "other"}},
+		{User: &discordgo.User{ID: "c"}},
+		{User: &discordgo.User{ID: "bot", Bot: true}, Roles: []string{"r1"}},
+	}
+	tiers := []db.Tier{{Name: "One"}, {Name: "Two"}}
+	roleIDs := []string{"r1", "r2"}
+	members := []map[string]bool{
+		{"a": true, "c": true},
+		{"b": true},
+	}
+
+	want := []roleChange{
+		{"b", "r1", tiers[0], false},
+		{"c", "r1", tiers[0], true},
+	}
+	if got := diffTierRoles(guildMembers, tiers, roleIDs, members); !reflect.DeepEqual(got, want) {
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+
+	members = []map[string]bool{{"a": true, "b": true}, {"b": true}}
+	if got := diffTierRoles(guildMembers, tiers, roleIDs, members); len(got) != 0 {
+		t.Errorf("Wanted no changes, got %v", got)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}, true},
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusBadGateway}}, true},
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}, false},
+		{errors.New("connection reset"), true},
+	}
+
+	for _, v := range cases {
+		if got := retryable(v.err); got != v.want {
+			t.Errorf("%v: wanted %v, got %v", v.err, v.want, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package rate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+var replyCooldowns = newCooldownMatrix(day)
+
+func init() {
+	config.Register("reply.value", "3", "respec given to someone when another user replies to them")
+	config.Register("reply.cooldown", "10", "minutes before replying to the same user gives them respec again")
+}
+
+// people replying to you means you said something worth answering
+func respecReply(guildID string, author *discordgo.User, message *discordgo.Message) (repliedID string) {
+	if message.MessageReference == nil || message.MessageReference.MessageID == "" {
+		return
+	}
+	original, err := state.Session.ChannelMessage(message.ChannelID, message.MessageReference.MessageID)
+	if err != nil || original.Author == nil {
+		return
+	}
+	repliedTo := original.Author
+	repliedID = repliedTo.ID
+	if repliedTo.Bot || repliedTo.ID == author.ID {
+		return
+	}
+
+	timeStamp, _ := message.Timestamp.Parse()
+	cooldown := time.Duration(config.Int(guildID, "reply.cooldown")) * time.Minute
+	// cooldowns are per guild, IDs are only digits so the slash keeps the pairs apart
+	if !replyCooldowns.allow(guildID+"/"+author.ID, repliedTo.ID, timeStamp, cooldown) {
+		logging.Log(fmt.Sprintf("%v replied to by %v too soon since last reply", repliedTo, author))
+		return
+	}
+
+	logging.Log(fmt.Sprintf("%v replied to by %v", repliedTo, author))
+	AddRespecReason(guildID, repliedTo, config.Int(guildID, "reply.value"), reasonReply, message.ID)
+	return
+}
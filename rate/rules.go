@@ -3,12 +3,19 @@ package rate
 import (
 	"math/big"
 	"strings"
+	"sync"
 
-	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/score"
 	"github.com/bwmarrin/discordgo"
 )
 
-type Rule func(*discordgo.User, *discordgo.Message) int
+type Rule func(*discordgo.User, *discordgo.Message, *parsedContent, *ruleProfile) int
+
+// a rule with the name profiles weight it by
+type namedRule struct {
+	name string
+	rule Rule
+}
 
 const (
 	bigValue   = 5
@@ -18,46 +25,32 @@ const (
 )
 
 var (
-	rules              []Rule
-	letters            map[rune]string
-	channelLastMessage map[string]*discordgo.Message
+	rules             []namedRule
+	channelLastAuthor map[string]string
+	channelMux        sync.Mutex
 )
 
 func init() {
-	rules = []Rule{lastPost,
-		respecLetters,
-		respecLength,
-		respecTime,
-	}
-
-	letters = make(map[rune]string)
-	channelLastMessage = make(map[string]*discordgo.Message)
-
-	var vowels = []rune{'a', 'e', 'i', 'o', 'u'}
-	var capVowels = []rune{'A', 'E', 'I', 'O', 'U'}
-	var consonants = []rune{'b', 'c', 'd', 'f', 'g', 'h', 'j', 'k', 'l', 'm', 'n', 'p', 'q', 'r', 's', 't', 'v', 'w', 'x', 'y', 'z'}
-	var capConsonants = []rune{'B', 'C', 'D', 'F', 'G', 'H', 'J', 'K', 'L', 'M', 'N', 'P', 'Q', 'R', 'S', 'T', 'V', 'W', 'X', 'Y', 'Z'}
-
-	for _, v := range vowels {
-		letters[v] = "vowel"
-	}
-
-	for _, v := range consonants {
-		letters[v] = "consonant"
-	}
-
-	for _, v := range capVowels {
-		letters[v] = "capVowel"
-	}
-
-	for _, v := range capConsonants {
-		letters[v] = "capConsonant"
-	}
+	rules = []namedRule{
+		{"lastpost", lastPost},
+		{"letters", respecLetters},
+		{"length", respecLength},
+		{"time", respecTime},
+		{"sentiment", respecSentiment},
+		{"spam", respecSpam},
+		{"media", respecMedia},
+		{"code", respecCode},
+		{"quotes", respecQuotes},
+	}
+
+	channelLastAuthor = make(map[string]string)
 }
 
-func applyRules(author *discordgo.User, message *discordgo.Message) (respec int) {
+// every rule weighted by the channel's profile
+func applyRules(author *discordgo.User, message *discordgo.Message, content *parsedContent) (respec int) {
+	profile := channelProfile(message.ChannelID)
 	for _, v := range rules {
-		respec += v(author, message)
+		respec += profile.weigh(v.name, v.rule(author, message, content, profile))
 	}
 	return
 }
@@ -68,114 +61,101 @@ func applyRules(author *discordgo.User, message *discordgo.Message) (respec int)
 // if you spam or barely talk fucc u
 
 // fuck you double posters
-func lastPost(author *discordgo.User, newMessage *discordgo.Message) (respec int) {
-	if message, ok := channelLastMessage[newMessage.ChannelID]; ok {
-		if message.Author.ID == author.ID {
-			respec -= minValue
-		} else {
-			respec += smallValue
-		}
+func lastPost(author *discordgo.User, newMessage *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	channelMux.Lock()
+	defer channelMux.Unlock()
 
-		if message.Content == newMessage.Content {
-			respec -= bigValue
-		}
+	if authorID, ok := channelLastAuthor[newMessage.ChannelID]; ok && authorID == author.ID {
+		respec -= minValue
 	} else {
 		respec += smallValue
 	}
 
-	channelLastMessage[newMessage.ChannelID] = newMessage
+	channelLastAuthor[newMessage.ChannelID] = author.ID
 	return
 }
 
-// fuck arbitrary amounts of letters
-func respecLetters(author *discordgo.User, message *discordgo.Message) (respec int) {
-	content := message.ContentWithMentionsReplaced()
-	var capsCount int64
-	var vowelCount int64
-	var consonantCount int64
-	var otherCount int64
+// fuck arbitrary amounts of letters, only in your own words
+func respecLetters(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	prose := content.words()
 
-	if len(content) < 1 {
-		return -smallValue
-	}
-
-	for _, c := range content {
-		switch letters[c] {
-		case "capVowel":
-			capsCount++
-			vowelCount++
-		case "vowel":
-			vowelCount++
-		case "capConsonant":
-			capsCount++
-			consonantCount++
-		case "consonant":
-			consonantCount++
-		default:
-			otherCount++
+	if len(prose) < 1 {
+		if hasMedia(message) || len(content.segments) > 0 {
+			return
 		}
+		return -smallValue
 	}
 
-	totalLetters := big.NewInt(consonantCount + vowelCount)
+	count := countLetters(prose)
+	totalLetters := count.letters()
 
-	if totalLetters.ProbablyPrime(2) && totalLetters.Int64() > 10 {
+	if primeLetters(prose) {
 		respec += bigValue
 	}
-	if totalLetters.Int64() == capsCount {
+	if totalLetters == count.caps {
 		respec -= bigValue
 	}
-	if vowelCount > consonantCount {
+	if count.vowels > count.consonants {
 		respec += minValue
-	} else if float64(vowelCount) < float64(consonantCount)*0.45 {
+	} else if float64(count.vowels) < float64(count.consonants)*0.45 {
 		respec -= smallValue
 	}
-	if otherCount > totalLetters.Int64() {
+	if count.other > totalLetters {
 		respec -= midValue
 	}
-	if capsCount < 1 && (vowelCount > 0 || consonantCount > 0) {
+	if count.caps < 1 && count.lower > 0 {
 		respec -= smallValue
 	} else {
 		respec += minValue
 	}
-	if end := content[len(content)-1:]; end == "." || end == "?" || end == "!" {
+	if runes := []rune(prose); endsSentence(runes[len(runes)-1]) {
 		respec += minValue
 	}
 	return
 }
 
-// fuck spammers and afk's
-func respecTime(author *discordgo.User, message *discordgo.Message) (respec int) {
+// fuck spammers, afk's are handled by decay
+func respecTime(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
 	timeStamp, _ := message.Timestamp.Parse()
-	if oldTime, ok := db.GetUserLastMessageTime(author.String()); ok {
+	if oldTime, ok := score.LastMessage(author.String()); ok {
 		timeDelta := timeStamp.Sub(oldTime)
 		if timeDelta.Seconds() < 1.5 {
 			respec -= smallValue
-		} else if timeDelta.Hours() > 6 {
-			available := db.GetUserRespec(author)
-
-			respec -= int(timeDelta.Hours()) * minValue
-
-			if available < 0 {
-				respec = 0
-			} else if available+respec < 0 {
-				respec = -available
-			}
 		}
 	}
 	return
 }
 
-// fucc 1 word replies or walls of text
-func respecLength(author *discordgo.User, message *discordgo.Message) (respec int) {
-	content := message.ContentWithMentionsReplaced()
+// a good long message with a prime number of letters
+func primeLetters(content string) bool {
+	totalLetters := big.NewInt(countLetters(content).letters())
+	return totalLetters.ProbablyPrime(2) && totalLetters.Int64() > 10
+}
 
-	words := strings.Split(content, " ")
-	length := len(words)
+// fucc 1 word replies or walls of text, code doesn't count as a wall
+func respecLength(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	length := len(strings.Fields(content.words()))
 
-	if length < 2 {
+	if length < profile.minWords && !hasMedia(message) && !content.hasCode(segmentCode, segmentInlineCode) {
 		respec -= smallValue
-	} else if length > 30 {
+	} else if length > profile.maxWords {
 		respec -= bigValue
 	}
 	return
 }
+
+// sharing code is helping
+func respecCode(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	if content.hasCode(segmentCode) {
+		respec += minValue
+	}
+	return
+}
+
+// quoting someone without saying anything yourself
+func respecQuotes(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	if content.has(segmentQuote) && content.words() == "" && !content.hasCode(segmentCode) && !hasMedia(message) {
+		respec -= minValue
+	}
+	return
+}
@@ -0,0 +1,247 @@
+package rate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/achievement"
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	seasonInterval = time.Hour
+	day            = 24 * time.Hour
+)
+
+var seasonMux sync.Mutex
+
+func init() {
+	config.Register("season.length", "0", "days in a season, 0 turns seasons off")
+	config.Register("season.carry", "0", "percent of their season respec members start the next season with, 0 starts everyone from nothing")
+	config.Register("season.winners", "3", "how many of the top members are announced and make the hall of fame")
+}
+
+// end seasons that are over and start the next ones
+func runSeasons() {
+	ticker := time.NewTicker(seasonInterval)
+	for range ticker.C {
+		checkSeasons(time.Now())
+	}
+}
+
+func checkSeasons(now time.Time) {
+	for _, guildID := range state.ActiveServers() {
+		length := config.Int(guildID, "season.length")
+		if length <= 0 {
+			continue
+		}
+
+		season, ok := db.GetCurrentSeason(guildID)
+		if !ok {
+			startSeason(guildID, 1, now)
+		} else if !now.Before(seasonEnd(season, length)) {
+			if err := endSeason(guildID, season, now); err != nil {
+				logging.Log(fmt.Sprintf("couldn't end season %v in %v: %v", season.Number, guildID, err))
+			}
+		}
+	}
+}
+
+func seasonEnd(season db.Season, length int) time.Time {
+	return season.Start.Add(time.Duration(length) * day)
+}
+
+func startSeason(guildID string, number int, now time.Time) {
+	db.AddSeason(&db.Season{GuildID: guildID, Number: number, Start: now})
+	logging.Log(fmt.Sprintf("season %v started in %v", number, guildID))
+}
+
+// archive the standings and start the next season, nobody's respec changes
+func endSeason(guildID string, season db.Season, now time.Time) error {
+	seasonMux.Lock()
+	defer seasonMux.Unlock()
+
+	// it might have been ended while we were waiting
+	if current, ok := db.GetCurrentSeason(guildID); !ok || current.ID != season.ID {
+		return nil
+	}
+
+	guild, err := state.Session.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	scores := guildSeasonScores(guildID, season)
+	var users []rankedUser
+	for _, v := range guild.Members {
+		if !v.User.Bot {
+			users = append(users, rankedUser{v.User.ID, scores[v.User.ID]})
+		}
+	}
+	sortRanked(users)
+
+	season.End = now
+	db.EndSeason(&season, seasonStandings(season.ID, users))
+	startSeason(guildID, season.Number+1, now)
+
+	winners := db.GetSeasonStandings(season.ID, config.Int(guildID, "season.winners"))
+	state.Announce(guildID, seasonResults(season, winners))
+	for _, v := range winners {
+		achievement.Fire(achievement.Event{Kind: achievement.SeasonEnded, UserID: v.UserID, GuildID: guildID, Count: v.Rank})
+	}
+	return nil
+}
+
+// The season score is what servers compete on, it starts over every season so newcomers can catch up.
+// Respec is shared between servers and never resets, tiers and the all time leaderboard keep going by it.
+// A member's season score is what they gained in the guild this season plus what they carried from the last one
+func guildSeasonScores(guildID string, season db.Season) map[string]int {
+	var previous []db.SeasonStanding
+	if ended := db.GetEndedSeasons(guildID, 1); len(ended) > 0 && ended[0].Number == season.Number-1 {
+		previous = db.GetSeasonStandings(ended[0].ID, 0)
+	}
+	return seasonScores(db.GetRespecSince(guildID, season.Start), previous, config.Int(guildID, "season.carry"))
+}
+
+func seasonScores(gained []db.Standing, previous []db.SeasonStanding, carry int) map[string]int {
+	scores := make(map[string]int)
+	for _, v := range previous {
+		if carried := seasonCarry(v.Respec, carry); carried != 0 {
+			scores[v.UserID] = carried
+		}
+	}
+	for _, v := range gained {
+		scores[v.UserID] += v.Respec
+	}
+	return scores
+}
+
+// the current season's standings
+func seasonBoard(guildID string) string {
+	season, ok := db.GetCurrentSeason(guildID)
+	if config.Int(guildID, "season.length") <= 0 || !ok {
+		return "This server doesn't have a season going, set `config season.length` to start them"
+	}
+
+	var users []rankedUser
+	for k, v := range guildSeasonScores(guildID, season) {
+		users = append(users, rankedUser{k, v})
+	}
+	sortRanked(users)
+	var standings []db.Standing
+	for _, v := range users {
+		standings = append(standings, db.Standing{UserID: v.id, Respec: v.respec})
+	}
+	return windowLeaderboard(fmt.Sprintf("Season %v", season.Number), standings)
+}
+
+// users have to be sorted with sortRanked
+func seasonStandings(seasonID uint64, users []rankedUser) (standings []db.SeasonStanding) {
+	for i, v := range users {
+		standings = append(standings, db.SeasonStanding{SeasonID: seasonID, UserID: v.id, Username: username(v.id), Rank: i + 1, Respec: v.respec})
+	}
+	return
+}
+
+// what's left of a season score going into the next season, positive and negative respec both move toward 0
+func seasonCarry(respec, carry int) int {
+	if carry <= 0 {
+		return 0
+	}
+	if carry >= 100 {
+		return respec
+	}
+	return respec * carry / 100
+}
+
+func seasonResults(season db.Season, winners []db.SeasonStanding) string {
+	reply := fmt.Sprintf("Season %v is over!", season.Number)
+	if len(winners) == 0 {
+		return reply + " Nobody played"
+	}
+	reply += "\n```\n"
+	for _, v := range winners {
+		reply += fmt.Sprintf("#%v %v (%v)\n", v.Rank, v.Username, v.Respec)
+	}
+	reply += "```"
+	return reply
+}
+
+// SeasonCmd Show the current season or end it early
+func SeasonCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	if len(args) > 1 && strings.ToLower(args[1]) == "help" {
+		reply := "```"
+		reply += "'season' - show the current season\n"
+		reply += "'season end' - end the season now and start the next one\n"
+		reply += "'stats season' - this season's standings\n"
+		reply += "Season scores start over every season, respec, tiers and 'stats' keep counting\n"
+		reply += "Use `config season.length`, `config season.carry` and `config season.winners` to set seasons up\n"
+		reply += "(Only server managers can end seasons)"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	length := config.Int(guildID, "season.length")
+	season, ok := db.GetCurrentSeason(guildID)
+	if length <= 0 {
+		state.SendReply(message.ChannelID, "This server doesn't have seasons, set `config season.length` to start them")
+		return
+	} else if !ok {
+		state.SendReply(message.ChannelID, "The first season starts within the hour")
+		return
+	}
+
+	if len(args) > 1 && strings.ToLower(args[1]) == "end" {
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+		if err := endSeason(guildID, season, time.Now()); err != nil {
+			state.SendReply(message.ChannelID, fmt.Sprintf("Couldn't end the season: %v", err))
+		}
+		return
+	}
+
+	left := seasonEnd(season, length).Sub(time.Now())
+	reply := fmt.Sprintf("Season %v started %v and ends in %v days %v hours", season.Number, season.Start.In(Location(guildID, message.Author.ID)).Format(dayFormat), int(left/day), int(left%day/time.Hour))
+	reply += fmt.Sprintf("\nYou have %v respec this season", guildSeasonScores(guildID, season)[message.Author.ID])
+	state.SendReply(message.ChannelID, reply)
+}
+
+// HallOfFameCmd Show the winners of past seasons
+func HallOfFameCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	seasons := db.GetEndedSeasons(guildID, 10)
+	if len(seasons) == 0 {
+		state.SendReply(message.ChannelID, "No seasons have ended yet")
+		return
+	}
+
+	winners := config.Int(guildID, "season.winners")
+	location := Location(guildID, message.Author.ID)
+	reply := "Hall of fame:\n```\n"
+	for _, season := range seasons {
+		reply += fmt.Sprintf("Season %v (%v to %v)\n", season.Number, season.Start.In(location).Format(dayFormat), season.End.In(location).Format(dayFormat))
+		for _, v := range db.GetSeasonStandings(season.ID, winners) {
+			reply += fmt.Sprintf("  #%v %v (%v)\n", v.Rank, v.Username, v.Respec)
+		}
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
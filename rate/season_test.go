@@ -0,0 +1,61 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/db"
+)
+
+func TestSeasonCarry(t *testing.T) {
+	cases := []struct {
+		respec, carry, want int
+	}{
+		{100, 0, 0},
+		{100, 25, 25},
+		{-100, 25, -25},
+		{7, 50, 3},
+		{-7, 50, -3},
+		{100, 100, 100},
+		{100, 150, 100},
+	}
+
+	for _, v := range cases {
+		if got := seasonCarry(v.respec, v.carry); got != v.want {
+			t.Errorf("%v with %v%% carried: wanted %v, got %v", v.respec, v.carry, v.want, got)
+		}
+	}
+}
+
+func TestSeasonStandings(t *testing.T) {
+	users := []rankedUser{{"b", 5}, {"a", 20}, {"c", -3}}
+	sortRanked(users)
+
+	standings := seasonStandings(7, users)
+	for i, id := range []string{"a", "b", "c"} {
+		if v := standings[i]; v.UserID != id || v.Rank != i+1 || v.SeasonID != 7 {
+			t.Errorf("Wanted %v at #%v, got %+v", id, i+1, v)
+		}
+	}
+
+	start := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	if end := seasonEnd(db.Season{Start: start}, 30); !end.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong season end %v", end)
+	}
+}
+
+func TestSeasonScores(t *testing.T) {
+	gained := []db.Standing{{UserID: "a", Respec: 10}, {UserID: "b", Respec: -4}}
+	previous := []db.SeasonStanding{{UserID: "a", Respec: 40}, {UserID: "c", Respec: 20}, {UserID: "d", Respec: 1}}
+
+	scores := seasonScores(gained, previous, 50)
+	want := map[string]int{"a": 30, "b": -4, "c": 10}
+	if !reflect.DeepEqual(scores, want) {
+		t.Errorf("Wanted %v, got %v", want, scores)
+	}
+
+	if scores = seasonScores(gained, previous, 0); scores["a"] != 10 || scores["c"] != 0 {
+		t.Errorf("Nothing should carry, got %v", scores)
+	}
+}
@@ -0,0 +1,238 @@
+package rate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// how many words after a negator get flipped
+	negationWindow  = 3
+	maxWordScore    = 5
+	strongSentiment = 6
+)
+
+var (
+	guildLexicons map[string]map[string]int
+	lexiconMux    sync.Mutex
+	customEmoji   = regexp.MustCompile(`<a?:(\w+):\d+>`)
+)
+
+func init() {
+	guildLexicons = make(map[string]map[string]int)
+}
+
+// be nice or else, quotes are someone else being nice or not
+func respecSentiment(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	score := scoreSentiment(content.prose(), guildLexicon(guildID))
+
+	switch {
+	case score >= strongSentiment:
+		respec += midValue
+	case score > 0:
+		respec += minValue
+	case score <= -strongSentiment:
+		respec -= midValue
+	case score < 0:
+		respec -= minValue
+	}
+	return
+}
+
+// add up the score of every word and emoji, guild words take priority over the base lexicon
+func scoreSentiment(content string, guildWords map[string]int) (score int) {
+	negated := 0
+	for _, token := range sentimentTokens(content) {
+		if token == "." {
+			negated = 0
+			continue
+		}
+
+		word := strings.Replace(token, "'", "", -1)
+		if negators[word] || strings.HasSuffix(token, "n't") {
+			negated = negationWindow
+			continue
+		}
+
+		value, ok := guildWords[word]
+		if !ok {
+			value, ok = baseLexicon[word]
+		}
+		if !ok {
+			value = baseEmoji[word]
+		}
+
+		if negated > 0 {
+			negated--
+			value = -value
+		}
+		score += value
+	}
+	return
+}
+
+// split content into lowercase words, emoji and "." for anything that ends a clause
+func sentimentTokens(content string) (tokens []string) {
+	content = customEmoji.ReplaceAllString(content, " $1 ")
+
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.Trim(string(word), "'"))
+			word = word[:0]
+		}
+	}
+
+	for i := 0; i < len(content); {
+		if i+2 <= len(content) {
+			if _, ok := baseEmoji[content[i:i+2]]; ok {
+				flush()
+				tokens = append(tokens, content[i:i+2])
+				i += 2
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(content[i:])
+		i += size
+
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '’':
+			if r == '’' {
+				r = '\''
+			}
+			word = append(word, unicode.ToLower(r))
+		case r == '.' || r == ',' || r == '!' || r == '?' || r == ';' || r == ':':
+			flush()
+			tokens = append(tokens, ".")
+		case unicode.IsSymbol(r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			// whitespace, variation selectors, joiners and anything else
+			flush()
+		}
+	}
+	flush()
+	return
+}
+
+func guildLexicon(guildID string) map[string]int {
+	lexiconMux.Lock()
+	defer lexiconMux.Unlock()
+
+	words, ok := guildLexicons[guildID]
+	if !ok {
+		words = make(map[string]int)
+		db.LoadLexicon(guildID, &words)
+		guildLexicons[guildID] = words
+	}
+	return words
+}
+
+// forget the cached words so they get reloaded next time
+func resetGuildLexicon(guildID string) {
+	lexiconMux.Lock()
+	delete(guildLexicons, guildID)
+	lexiconMux.Unlock()
+}
+
+// LexiconCmd Handle the lexicon command to view or change the guild's sentiment words
+func LexiconCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || args[1] == "help" {
+		reply := "```"
+		reply += "'lexicon help' - display this message\n"
+		reply += "'lexicon list' - display the words this server added\n"
+		reply += "'lexicon score [text]' - show how the text would be scored\n"
+		reply += "'lexicon add [word] [score]' - add or change a word, score is -5 to 5\n"
+		reply += "'lexicon remove [word]' - remove a word this server added\n"
+		reply += "(Only server managers can add/remove words)"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	switch strings.ToLower(args[1]) {
+	case "list":
+		words := guildLexicon(guildID)
+		if len(words) == 0 {
+			state.SendReply(message.ChannelID, "This server has not added any words")
+			return
+		}
+		var keys []string
+		for k := range words {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		reply := "```\n"
+		for _, k := range keys {
+			reply += fmt.Sprintf("%v %+d\n", k, words[k])
+		}
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+
+	case "score":
+		text := strings.Join(args[2:], " ")
+		score := scoreSentiment(text, guildLexicon(guildID))
+		state.SendReply(message.ChannelID, fmt.Sprintf("Sentiment: %+d", score))
+
+	case "add":
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+		if len(args) < 4 {
+			state.SendReply(message.ChannelID, "Usage: `lexicon add [word] [score]`")
+			return
+		}
+		score, err := strconv.Atoi(args[3])
+		if err != nil || score < -maxWordScore || score > maxWordScore {
+			state.SendReply(message.ChannelID, "Invalid score")
+			return
+		}
+		word := strings.ToLower(args[2])
+		db.SetLexiconWord(guildID, word, score)
+		resetGuildLexicon(guildID)
+		state.SendReply(message.ChannelID, fmt.Sprintf("`%v` is now worth %+d", word, score))
+
+	case "remove":
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+		if len(args) < 3 {
+			state.SendReply(message.ChannelID, "Usage: `lexicon remove [word]`")
+			return
+		}
+		word := strings.ToLower(args[2])
+		if db.RemoveLexiconWord(guildID, word) {
+			resetGuildLexicon(guildID)
+			state.SendReply(message.ChannelID, fmt.Sprintf("Removed `%v`", word))
+		} else {
+			state.SendReply(message.ChannelID, fmt.Sprintf("`%v` was not added by this server", word))
+		}
+
+	default:
+		state.SendReply(message.ChannelID, "Not a valid lexicon command, use `lexicon help`")
+	}
+}
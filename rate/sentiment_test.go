@@ -0,0 +1,56 @@
+package rate
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScoreSentiment(t *testing.T) {
+	file, err := os.Open("testdata/sentiment.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			t.Fatalf("Bad fixture line: %q", line)
+		}
+		want, err := strconv.Atoi(fields[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := scoreSentiment(fields[1], nil); got != want {
+			t.Errorf("%q: wanted %v, got %v", fields[1], want, got)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScoreSentimentGuildWords(t *testing.T) {
+	guildWords := map[string]int{"pepehands": -2, "good": 0}
+
+	if got := scoreSentiment("pepehands", guildWords); got != -2 {
+		t.Errorf("Guild word not used, got %v", got)
+	}
+	if got := scoreSentiment("<:pepehands:1234>", guildWords); got != -2 {
+		t.Errorf("Custom emoji not scored by name, got %v", got)
+	}
+	if got := scoreSentiment("good", guildWords); got != 0 {
+		t.Errorf("Guild word did not override base lexicon, got %v", got)
+	}
+	if got := scoreSentiment("not pepehands", guildWords); got != 2 {
+		t.Errorf("Guild word not negated, got %v", got)
+	}
+}
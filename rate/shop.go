@@ -0,0 +1,417 @@
+package rate
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// what shop items do
+const (
+	perkColor    = "color"
+	perkNickname = "nickname"
+	perkPin      = "pin"
+	perkTitle    = "title"
+	perkFreeze   = "freeze"
+)
+
+const shopInterval = 5 * time.Minute
+
+var (
+	perkKinds = map[string]string{
+		perkColor:    "a role with your own color, buy with `buy [id] #rrggbb`",
+		perkNickname: "a new nickname, buy with `buy [id] [nickname]`",
+		perkPin:      "pin a message in the channel, buy with `buy [id] [message id]`",
+		perkTitle:    "a title on your profile, buy with `buy [id] [title]`",
+		perkFreeze:   "a streak freeze that covers a missed day",
+	}
+	// you only get one of these at a time
+	perkSingle = map[string]bool{perkColor: true, perkNickname: true, perkTitle: true}
+
+	// one purchase at a time for each user in a guild, from the checks until it's paid for
+	buyerMuxes map[buyer]*sync.Mutex
+	buyerMux   sync.Mutex
+)
+
+type buyer struct {
+	guildID string
+	userID  string
+}
+
+func init() {
+	buyerMuxes = make(map[buyer]*sync.Mutex)
+}
+
+func buyerLock(guildID, userID string) *sync.Mutex {
+	buyerMux.Lock()
+	defer buyerMux.Unlock()
+
+	mux, ok := buyerMuxes[buyer{guildID, userID}]
+	if !ok {
+		mux = new(sync.Mutex)
+		buyerMuxes[buyer{guildID, userID}] = mux
+	}
+	return mux
+}
+
+// revert perks that have run out, ones that couldn't be reverted are tried again next time
+func expirePurchases() {
+	ticker := time.NewTicker(shopInterval)
+	for range ticker.C {
+		for _, v := range db.GetExpiredPurchases(time.Now()) {
+			if err := revertPerk(v); err != nil && !gone(err) {
+				logging.Log(fmt.Sprintf("couldn't revert %v for %v, trying again later: %v", v.Name, v.UserID, err))
+				continue
+			}
+			db.ExpirePurchase(v.ID)
+		}
+	}
+}
+
+// the role, member or pin isn't there any more so there's nothing to revert
+func gone(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	return ok && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// kind price stock hours name
+func parseShopItem(guildID string, args []string) (item db.ShopItem, err error) {
+	if len(args) < 5 {
+		return item, fmt.Errorf("Usage: `shop add [kind] [price] [stock] [hours] [name]`, use -1 stock for no limit and 0 hours for forever")
+	}
+
+	item.GuildID = guildID
+	item.Kind = strings.ToLower(args[0])
+	if _, ok := perkKinds[item.Kind]; !ok {
+		return item, fmt.Errorf("Invalid kind, use color, nickname, pin, title or freeze")
+	}
+	if item.Price, err = strconv.Atoi(args[1]); err != nil || item.Price < 1 {
+		return item, fmt.Errorf("Invalid price")
+	}
+	if item.Stock, err = strconv.Atoi(args[2]); err != nil {
+		return item, fmt.Errorf("Invalid stock")
+	}
+	if item.Hours, err = strconv.Atoi(args[3]); err != nil || item.Hours < 0 {
+		return item, fmt.Errorf("Invalid hours")
+	}
+	item.Name = strings.Join(args[4:], " ")
+	return item, nil
+}
+
+// what the buyer wants out of the perk
+func perkValue(kind string, args []string) (string, error) {
+	value := strings.TrimSpace(strings.Join(args, " "))
+	switch kind {
+	case perkColor:
+		color, err := strconv.ParseInt(strings.TrimPrefix(value, "#"), 16, 32)
+		if err != nil || !strings.HasPrefix(value, "#") || color > 0xffffff {
+			return "", fmt.Errorf("Pick a color like #ff8800")
+		}
+		return fmt.Sprintf("#%06x", color), nil
+	case perkNickname:
+		if length := len([]rune(value)); length < 1 || length > 32 {
+			return "", fmt.Errorf("Nicknames have to be 1 to 32 characters")
+		}
+	case perkTitle:
+		if length := len([]rune(value)); length < 1 || length > 50 {
+			return "", fmt.Errorf("Titles have to be 1 to 50 characters")
+		}
+	case perkPin:
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return "", fmt.Errorf("Give me the ID of the message to pin")
+		}
+	}
+	return value, nil
+}
+
+// make the perk happen, what it returns is kept so it can be undone
+func applyPerk(guildID, channelID string, user *discordgo.User, kind, value string) (applied, old string, err error) {
+	switch kind {
+	case perkColor:
+		color, _ := strconv.ParseInt(strings.TrimPrefix(value, "#"), 16, 32)
+		role, err := state.Session.GuildRoleCreate(guildID)
+		if err != nil {
+			return "", "", err
+		}
+		if _, err = state.Session.GuildRoleEdit(guildID, role.ID, user.Username, int(color), false, 0, false); err != nil {
+			state.Session.GuildRoleDelete(guildID, role.ID)
+			return "", "", err
+		}
+		if err = state.Session.GuildMemberRoleAdd(guildID, user.ID, role.ID); err != nil {
+			state.Session.GuildRoleDelete(guildID, role.ID)
+			return "", "", err
+		}
+		return role.ID, "", nil
+	case perkNickname:
+		member, err := state.Session.GuildMember(guildID, user.ID)
+		if err != nil {
+			return "", "", err
+		}
+		return value, member.Nick, state.Session.GuildMemberNickname(guildID, user.ID, value)
+	case perkPin:
+		return channelID + "/" + value, "", state.Session.ChannelMessagePin(channelID, value)
+	}
+	return value, "", nil
+}
+
+func revertPerk(purchase db.Purchase) error {
+	switch purchase.Kind {
+	case perkColor:
+		return state.Session.GuildRoleDelete(purchase.GuildID, purchase.Value)
+	case perkNickname:
+		return state.Session.GuildMemberNickname(purchase.GuildID, purchase.UserID, purchase.OldValue)
+	case perkPin:
+		ids := strings.SplitN(purchase.Value, "/", 2)
+		if len(ids) == 2 {
+			return state.Session.ChannelMessageUnpin(ids[0], ids[1])
+		}
+	}
+	return nil
+}
+
+// bought freezes count toward the same limit as earned ones
+func addFreeze(guildID, userID string) bool {
+	streakMux.Lock()
+	defer streakMux.Unlock()
+
+	streak := db.GetStreak(userID, guildID)
+	if streak.Freezes >= config.Int(guildID, "streak.freezes") {
+		return false
+	}
+	streak.Freezes++
+	db.SetStreak(streak)
+	return true
+}
+
+// pay first and then apply the perk, if it can't be applied the purchase is refunded
+func buy(guildID string, message *discordgo.Message, item db.ShopItem, args []string) error {
+	user := message.Author
+	value, err := perkValue(item.Kind, args)
+	if err != nil {
+		return err
+	}
+
+	mux := buyerLock(guildID, user.ID)
+	mux.Lock()
+	defer mux.Unlock()
+
+	if perkSingle[item.Kind] {
+		for _, v := range db.GetActivePurchases(guildID, user.ID) {
+			if v.Kind == item.Kind {
+				return fmt.Errorf("You already have a %v, wait for it to run out", item.Kind)
+			}
+		}
+	}
+	if item.Kind == perkFreeze && db.GetStreak(user.ID, guildID).Freezes >= config.Int(guildID, "streak.freezes") {
+		return fmt.Errorf("You can't hold any more freezes")
+	}
+	if score.GetByID(user.ID) < item.Price {
+		return fmt.Errorf("You can't afford that")
+	}
+
+	now := time.Now()
+	purchase := db.Purchase{GuildID: guildID, UserID: user.ID, ItemID: item.ID, Name: item.Name, Kind: item.Kind,
+		Value: value, Price: item.Price, Time: now, Expired: item.Kind == perkFreeze}
+	if item.Hours > 0 && item.Kind != perkFreeze {
+		purchase.Expires = now.Add(time.Duration(item.Hours) * time.Hour)
+	}
+
+	err = score.Spend(user.ID, item.Price, func() bool {
+		return db.BuyItem(&purchase, reasonShop)
+	})
+	if err == score.ErrRefused {
+		return fmt.Errorf("%v is sold out", item.Name)
+	} else if err != nil {
+		return fmt.Errorf("You can't afford that")
+	}
+
+	if item.Kind == perkFreeze {
+		if !addFreeze(guildID, user.ID) {
+			refund(purchase)
+			return fmt.Errorf("You can't hold any more freezes, you got your respec back")
+		}
+	} else {
+		applied, old, err := applyPerk(guildID, message.ChannelID, user, item.Kind, value)
+		if err != nil {
+			refund(purchase)
+			return fmt.Errorf("I couldn't do that, you got your respec back: %v", err)
+		}
+		db.SetPurchaseValue(purchase.ID, applied, old)
+	}
+
+	logging.Log(fmt.Sprintf("%v bought %v for %v", user, item.Name, item.Price))
+	scheduleReconcile(guildID)
+	return nil
+}
+
+// give back what a purchase cost, it doesn't get flipped
+func refund(purchase db.Purchase) {
+	logging.Log(fmt.Sprintf("refunding %v %v for %v", purchase.UserID, purchase.Price, purchase.Name))
+	score.AddByID(purchase.UserID, purchase.Price)
+	db.RefundPurchase(purchase, reasonShop)
+	scheduleReconcile(purchase.GuildID)
+}
+
+func describeItem(item db.ShopItem) string {
+	line := fmt.Sprintf("%v. %v (%v) - %v respec", item.ID, item.Name, item.Kind, item.Price)
+	if item.Hours > 0 {
+		line += fmt.Sprintf(", lasts %v hours", item.Hours)
+	}
+	if item.Stock == 0 {
+		line += ", sold out"
+	} else if item.Stock > 0 {
+		line += fmt.Sprintf(", %v left", item.Stock)
+	}
+	return line
+}
+
+// ShopCmd List what's for sale or change it
+func ShopCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	cmd := "list"
+	if len(args) > 1 {
+		cmd = strings.ToLower(args[1])
+	}
+
+	switch cmd {
+	case "list":
+		items := db.GetShopItems(guildID)
+		if len(items) == 0 {
+			state.SendReply(message.ChannelID, "There's nothing for sale")
+			return
+		}
+		reply := "For sale, use `buy [id]`:\n```\n"
+		for _, v := range items {
+			reply += describeItem(v) + "\n"
+		}
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+
+	case "help":
+		reply := "```"
+		reply += "'shop' - see what's for sale\n"
+		reply += "'shop add [kind] [price] [stock] [hours] [name]' - sell something, -1 stock for no limit and 0 hours for forever\n"
+		reply += "'shop remove [id]' - stop selling something\n"
+		reply += "Kinds:\n"
+		for _, k := range []string{perkColor, perkNickname, perkPin, perkTitle, perkFreeze} {
+			reply += fmt.Sprintf("  %v - %v\n", k, perkKinds[k])
+		}
+		reply += "(Only server managers can change the shop)"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+
+	case "add", "remove":
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+
+	default:
+		state.SendReply(message.ChannelID, "Not a valid shop command, use `shop help`")
+		return
+	}
+
+	if cmd == "add" {
+		item, err := parseShopItem(guildID, args[2:])
+		if err != nil {
+			state.SendReply(message.ChannelID, err.Error())
+			return
+		}
+		db.AddShopItem(&item)
+		state.SendReply(message.ChannelID, "Now selling "+describeItem(item))
+		return
+	}
+
+	if len(args) < 3 {
+		state.SendReply(message.ChannelID, "Usage: `shop remove [id]`")
+		return
+	}
+	id, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil || !db.RemoveShopItem(guildID, id) {
+		state.SendReply(message.ChannelID, "No item with that id")
+		return
+	}
+	state.SendReply(message.ChannelID, "Removed it, anything already bought lasts until it runs out")
+}
+
+// BuyCmd Spend respec on something from the shop
+func BuyCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	if len(args) < 2 {
+		state.SendReply(message.ChannelID, "Usage: `buy [id]`, see what's for sale with `shop`")
+		return
+	}
+
+	id, err := strconv.ParseUint(args[1], 10, 64)
+	item, ok := db.GetShopItem(guildID, id)
+	if err != nil || !ok {
+		state.SendReply(message.ChannelID, "No item with that id")
+		return
+	}
+
+	if err := buy(guildID, message, item, args[2:]); err != nil {
+		state.SendReply(message.ChannelID, err.Error())
+		return
+	}
+	state.SendReply(message.ChannelID, fmt.Sprintf("%v bought %v", message.Author.Username, item.Name))
+}
+
+// InventoryCmd Show what a user has bought that hasn't run out
+func InventoryCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	user := message.Author
+	if len(message.Mentions) > 0 {
+		user = message.Mentions[0]
+	}
+
+	purchases := db.GetActivePurchases(guildID, user.ID)
+	if len(purchases) == 0 {
+		state.SendReply(message.ChannelID, fmt.Sprintf("%v hasn't got anything", user.Username))
+		return
+	}
+
+	location := Location(guildID, message.Author.ID)
+	reply := fmt.Sprintf("%v's stuff:\n```\n", user.Username)
+	for _, v := range purchases {
+		reply += fmt.Sprintf("%v (%v)", v.Name, v.Kind)
+		if v.Expires.IsZero() {
+			reply += " forever\n"
+		} else {
+			reply += fmt.Sprintf(" until %v\n", LocalTime(v.Expires, location, "2006-01-02 15:04"))
+		}
+	}
+	reply += "```"
+	state.SendReply(message.ChannelID, reply)
+}
+
+// the title a user bought, if they have one
+func activeTitle(guildID, userID string) string {
+	for _, v := range db.GetActivePurchases(guildID, userID) {
+		if v.Kind == perkTitle {
+			return v.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package rate
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseShopItem(t *testing.T) {
+	item, err := parseShopItem("1", []string{"Color", "100", "-1", "24", "Fancy", "Color"})
+	want := db.ShopItem{GuildID: "1", Name: "Fancy Color", Kind: perkColor, Price: 100, Stock: -1, Hours: 24}
+	if err != nil || item != want {
+		t.Errorf("Wanted %+v, got %+v %v", want, item, err)
+	}
+
+	bad := [][]string{
+		{"hat", "100", "-1", "24", "Hat"},
+		{"title", "0", "-1", "24", "Free"},
+		{"title", "10", "lots", "24", "Title"},
+		{"title", "10", "5", "-2", "Title"},
+		{"title", "10", "5", "24"},
+	}
+	for _, v := range bad {
+		if _, err := parseShopItem("1", v); err == nil {
+			t.Errorf("%v was accepted", v)
+		}
+	}
+}
+
+func TestPerkValue(t *testing.T) {
+	cases := []struct {
+		kind string
+		args []string
+		want string
+		ok   bool
+	}{
+		{perkColor, []string{"#FF8800"}, "#ff8800", true},
+		{perkColor, []string{"ff8800"}, "", false},
+		{perkColor, []string{"#1000000"}, "", false},
+		{perkNickname, []string{"Big", "Boss"}, "Big Boss", true},
+		{perkNickname, nil, "", false},
+		{perkTitle, []string{"The", "Great"}, "The Great", true},
+		{perkPin, []string{"123456789"}, "123456789", true},
+		{perkPin, []string{"that", "one"}, "", false},
+		{perkFreeze, nil, "", true},
+	}
+
+	for _, v := range cases {
+		got, err := perkValue(v.kind, v.args)
+		if got != v.want || (err == nil) != v.ok {
+			t.Errorf("%v %v: wanted %q %v, got %q %v", v.kind, v.args, v.want, v.ok, got, err)
+		}
+	}
+}
+
+func TestGone(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}, true},
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusForbidden}}, false},
+		{&discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusBadGateway}}, false},
+		{errors.New("connection reset"), false},
+	}
+
+	for _, v := range cases {
+		if got := gone(v.err); got != v.want {
+			t.Errorf("%v: wanted %v, got %v", v.err, v.want, got)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package rate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// how long messages are remembered
+	spamWindow = 10 * time.Minute
+	// most messages remembered per user and for everyone together
+	userSpamHistory   = 25
+	globalSpamHistory = 250
+	shingleSize       = 4
+	// shorter messages than this are only checked for bursts, everyone says "lol" twice
+	minSpamLength = 10
+	// jaccard similarity of shingles where two messages count as the same
+	duplicateSimilarity = 0.7
+	burstWindow         = 10 * time.Second
+	burstLimit          = 5
+	// how many other users have to post the same thing for it to be copypasta
+	copypastaUsers = 2
+	// strikes are forgotten after this long without spamming
+	strikeCooldown = 30 * time.Minute
+	maxSpamPenalty = 4 * bigValue
+)
+
+type spamMessage struct {
+	userID    string
+	channelID string
+	time      time.Time
+	shingles  map[uint64]bool
+}
+
+type spamUser struct {
+	messages   []*spamMessage
+	strikes    int
+	lastStrike time.Time
+}
+
+type spamVerdict struct {
+	duplicate    bool
+	crossChannel bool
+	burst        bool
+	copypasta    bool
+	strikes      int
+}
+
+// keeps a sliding window of what everyone has said recently
+type spamDetector struct {
+	mux       sync.Mutex
+	users     map[string]*spamUser
+	recent    []*spamMessage
+	lastPrune time.Time
+}
+
+var spam *spamDetector
+
+func init() {
+	spam = newSpamDetector()
+}
+
+func newSpamDetector() *spamDetector {
+	return &spamDetector{users: make(map[string]*spamUser)}
+}
+
+// fuck spammers, more every time
+func respecSpam(author *discordgo.User, message *discordgo.Message, content *parsedContent, profile *ruleProfile) (respec int) {
+	timeStamp, _ := message.Timestamp.Parse()
+	verdict := spam.check(author.ID, message.ChannelID, content.raw, timeStamp)
+
+	if verdict.strikes == 0 {
+		return
+	}
+
+	logging.Log(fmt.Sprintf("%v spam strike %v %+v", author, verdict.strikes, verdict))
+
+	respec -= smallValue * verdict.strikes
+	if respec < -maxSpamPenalty {
+		respec = -maxSpamPenalty
+	}
+	return
+}
+
+func (v spamVerdict) isSpam() bool {
+	return v.duplicate || v.burst || v.copypasta
+}
+
+// remember the message and see if it looks like spam
+func (d *spamDetector) check(userID, channelID, content string, timeStamp time.Time) (verdict spamVerdict) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.prune(timeStamp)
+
+	user, ok := d.users[userID]
+	if !ok {
+		user = new(spamUser)
+		d.users[userID] = user
+	}
+
+	msg := &spamMessage{userID: userID, channelID: channelID, time: timeStamp, shingles: shingles(content)}
+
+	recentCount := 0
+	for _, v := range user.messages {
+		if timeStamp.Sub(v.time) > spamWindow {
+			continue
+		}
+		if timeStamp.Sub(v.time) < burstWindow {
+			recentCount++
+		}
+		if len(msg.shingles) > 0 && similarity(msg.shingles, v.shingles) >= duplicateSimilarity {
+			verdict.duplicate = true
+			if v.channelID != channelID {
+				verdict.crossChannel = true
+			}
+		}
+	}
+	verdict.burst = recentCount+1 >= burstLimit
+
+	if len(msg.shingles) > 0 {
+		others := make(map[string]bool)
+		for _, v := range d.recent {
+			if v.userID != userID && similarity(msg.shingles, v.shingles) >= duplicateSimilarity {
+				others[v.userID] = true
+			}
+		}
+		verdict.copypasta = len(others) >= copypastaUsers
+	}
+
+	if verdict.isSpam() {
+		if timeStamp.Sub(user.lastStrike) > strikeCooldown {
+			user.strikes = 0
+		}
+		user.strikes++
+		user.lastStrike = timeStamp
+		verdict.strikes = user.strikes
+	}
+
+	user.messages = append(user.messages, msg)
+	if len(user.messages) > userSpamHistory {
+		user.messages = user.messages[len(user.messages)-userSpamHistory:]
+	}
+	d.recent = append(d.recent, msg)
+	if len(d.recent) > globalSpamHistory {
+		d.recent = d.recent[len(d.recent)-globalSpamHistory:]
+	}
+	return
+}
+
+// forget anything that fell out of the window
+func (d *spamDetector) prune(now time.Time) {
+	for len(d.recent) > 0 && now.Sub(d.recent[0].time) > spamWindow {
+		d.recent = d.recent[1:]
+	}
+
+	if now.Sub(d.lastPrune) < time.Minute {
+		return
+	}
+	d.lastPrune = now
+
+	for k, user := range d.users {
+		i := 0
+		for i < len(user.messages) && now.Sub(user.messages[i].time) > spamWindow {
+			i++
+		}
+		user.messages = user.messages[i:]
+		if len(user.messages) == 0 && now.Sub(user.lastStrike) > strikeCooldown {
+			delete(d.users, k)
+		}
+	}
+}
+
+// hashed character shingles of the message with case, spacing and punctuation ignored, none if it's too short to compare
+func shingles(content string) (set map[uint64]bool) {
+	var normalized []rune
+	for _, c := range strings.ToLower(content) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			normalized = append(normalized, c)
+		}
+	}
+
+	set = make(map[uint64]bool)
+	if len(normalized) < minSpamLength {
+		return
+	}
+	for i := 0; i+shingleSize <= len(normalized); i++ {
+		set[hashShingle(normalized[i:i+shingleSize])] = true
+	}
+	return
+}
+
+func hashShingle(shingle []rune) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(string(shingle)))
+	return h.Sum64()
+}
+
+// jaccard similarity of two shingle sets
+func similarity(a, b map[uint64]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
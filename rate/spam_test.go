@@ -0,0 +1,113 @@
+package rate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpamDuplicates(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+
+	if v := d.check("a", "1", "has anyone seen my keys anywhere", now); v.isSpam() {
+		t.Errorf("First message was spam: %+v", v)
+	}
+	v := d.check("a", "2", "Has anyone seen my keys anywhere??", now.Add(time.Minute))
+	if !v.duplicate || !v.crossChannel || v.strikes != 1 {
+		t.Errorf("Near duplicate in another channel not caught: %+v", v)
+	}
+	v = d.check("a", "1", "has anyone seen my keys anywhere lol", now.Add(2*time.Minute))
+	if !v.duplicate || v.strikes != 2 {
+		t.Errorf("Strikes did not escalate: %+v", v)
+	}
+	if v = d.check("a", "1", "never mind they were in the fridge", now.Add(3*time.Minute)); v.isSpam() {
+		t.Errorf("Different message was spam: %+v", v)
+	}
+	if v = d.check("a", "1", "has anyone seen my keys anywhere", now.Add(spamWindow+4*time.Minute)); v.isSpam() {
+		t.Errorf("Message outside of window was spam: %+v", v)
+	}
+}
+
+func TestSpamStrikeCooldown(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+
+	d.check("a", "1", "buy my mixtape", now)
+	d.check("a", "1", "buy my mixtape", now.Add(time.Minute))
+	d.check("a", "1", "buy my mixtape", now.Add(strikeCooldown+2*time.Minute))
+	v := d.check("a", "1", "buy my mixtape", now.Add(strikeCooldown+3*time.Minute))
+	if v.strikes != 1 {
+		t.Errorf("Strikes were not reset after cooldown: %+v", v)
+	}
+}
+
+func TestSpamBurst(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+	messages := []string{"one", "two things", "three", "fourth thing here", "and five"}
+
+	var v spamVerdict
+	for i, m := range messages {
+		v = d.check("a", "1", m, now.Add(time.Duration(i)*time.Second))
+	}
+	if !v.burst {
+		t.Errorf("Burst not caught: %+v", v)
+	}
+}
+
+func TestSpamCopypasta(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+	pasta := "What the heck did you just say about me, you little rascal?"
+
+	d.check("a", "1", pasta, now)
+	if v := d.check("b", "1", pasta, now.Add(time.Second)); v.copypasta {
+		t.Errorf("Copypasta after one user: %+v", v)
+	}
+	if v := d.check("c", "2", pasta, now.Add(2*time.Second)); !v.copypasta {
+		t.Errorf("Copypasta not caught: %+v", v)
+	}
+}
+
+func TestSpamConcurrent(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+	done := make(chan bool)
+
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			for j := 0; j < 100; j++ {
+				d.check(string(rune('a'+i)), "1", "hello there", now.Add(time.Duration(j)*time.Second))
+			}
+			done <- true
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		<-done
+	}
+}
+
+func TestSpamShortReplies(t *testing.T) {
+	d := newSpamDetector()
+	now := time.Now()
+
+	d.check("a", "1", "lol", now)
+	if v := d.check("a", "1", "LOL!", now.Add(time.Minute)); v.isSpam() {
+		t.Errorf("Saying lol twice was spam: %+v", v)
+	}
+	d.check("b", "1", "lol", now.Add(2*time.Minute))
+	if v := d.check("c", "1", "lol", now.Add(3*time.Minute)); v.isSpam() {
+		t.Errorf("Everyone saying lol was spam: %+v", v)
+	}
+	if v := d.check("a", "1", "ok", now.Add(4*time.Minute)); v.isSpam() {
+		t.Errorf("Short reply was spam: %+v", v)
+	}
+
+	var v spamVerdict
+	for i := 0; i < burstLimit; i++ {
+		v = d.check("d", "1", "ok", now.Add(time.Duration(i)*time.Second))
+	}
+	if !v.burst || v.duplicate {
+		t.Errorf("Short messages should only be burst checked: %+v", v)
+	}
+}
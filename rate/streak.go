@@ -0,0 +1,182 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/achievement"
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	dayFormat      = "2006-01-02"
+	streakInterval = time.Hour
+)
+
+var streakMux sync.Mutex
+
+func init() {
+	config.RegisterChoice("streak.curve", "linear", "how the daily streak bonus grows, step grows once a week", "none", "linear", "log", "step")
+	config.Register("streak.bonus", "1", "respec the streak bonus grows by")
+	config.Register("streak.max", "10", "the biggest daily streak bonus")
+	config.Register("streak.freezeevery", "7", "days of streak it takes to earn a freeze, 0 to never earn them")
+	config.Register("streak.freezes", "2", "most freezes a user can hold, each one covers a missed day")
+	config.Register("streak.warn", "3", "streaks this long get a warning when they're about to end, 0 for no warnings")
+	config.Register("streak.warnhour", "20", "hour of the day to warn about streaks that are about to end")
+}
+
+// count the user's first message each day toward their streak
+func recordActivity(guildID string, user *discordgo.User, channelID string, timeStamp time.Time) {
+	streakMux.Lock()
+	defer streakMux.Unlock()
+
+	today := timeStamp.In(Location(guildID, user.ID)).Format(dayFormat)
+	streak, extended := advanceStreak(db.GetStreak(user.ID, guildID), today,
+		config.Int(guildID, "streak.freezeevery"), config.Int(guildID, "streak.freezes"))
+	if !extended {
+		return
+	}
+	db.SetStreak(streak)
+
+	bonus := streakBonus(config.String(guildID, "streak.curve"), config.Int(guildID, "streak.bonus"), config.Int(guildID, "streak.max"), streak.Current)
+	if bonus != 0 {
+		logging.Log(fmt.Sprintf("%v is on a %v day streak", user, streak.Current))
+		AddRespecReason(guildID, user, bonus, reasonStreak, "")
+	}
+	achievement.Fire(achievement.Event{Kind: achievement.Streak, UserID: user.ID, GuildID: guildID, ChannelID: channelID, Count: streak.Current})
+}
+
+// move the streak to today, freezes cover missed days if there's enough of them
+func advanceStreak(streak db.Streak, today string, freezeEvery, maxFreezes int) (db.Streak, bool) {
+	if streak.LastDay == today {
+		return streak, false
+	}
+
+	missed := daysBetween(streak.LastDay, today) - 1
+	if streak.LastDay == "" || missed < 0 {
+		streak.Current = 1
+	} else if missed <= streak.Freezes {
+		streak.Freezes -= missed
+		streak.Current++
+	} else {
+		streak.Current = 1
+	}
+	streak.LastDay = today
+
+	if streak.Current > streak.Best {
+		streak.Best = streak.Current
+	}
+	if freezeEvery > 0 && streak.Current%freezeEvery == 0 && streak.Freezes < maxFreezes {
+		streak.Freezes++
+	}
+	return streak, true
+}
+
+// whole days from one day to another, both in dayFormat
+func daysBetween(from, to string) int {
+	fromDay, err := time.Parse(dayFormat, from)
+	if err != nil {
+		return 0
+	}
+	toDay, err := time.Parse(dayFormat, to)
+	if err != nil {
+		return 0
+	}
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// respec for keeping a streak going, nothing on the first day
+func streakBonus(curve string, bonus, max, days int) (respec int) {
+	switch curve {
+	case "linear":
+		respec = bonus * (days - 1)
+	case "log":
+		respec = bonus * int(math.Log2(float64(days)))
+	case "step":
+		respec = bonus * (days / 7)
+	}
+	if respec > max {
+		respec = max
+	}
+	return
+}
+
+// tell users their streak ends at midnight if they haven't said anything today
+func warnStreaks() {
+	ticker := time.NewTicker(streakInterval)
+	for range ticker.C {
+		for _, guildID := range state.ActiveServers() {
+			warnGuildStreaks(guildID, time.Now())
+		}
+	}
+}
+
+// the warning goes out at the warn hour in each user's own timezone
+func warnGuildStreaks(guildID string, now time.Time) {
+	minimum := config.Int(guildID, "streak.warn")
+	warnHour := config.Int(guildID, "streak.warnhour")
+	if minimum <= 0 {
+		return
+	}
+
+	streakMux.Lock()
+	defer streakMux.Unlock()
+
+	// timezones can put users a day either side of the guild
+	since := now.In(guildLocation(guildID)).AddDate(0, 0, -2).Format(dayFormat)
+	for _, v := range db.GetStreaksSince(guildID, since, minimum) {
+		local := now.In(Location(guildID, v.UserID))
+		today := local.Format(dayFormat)
+		if local.Hour() < warnHour || v.LastDay == today || v.Warned == today || v.Freezes > 0 {
+			continue
+		}
+		if daysBetween(v.LastDay, today) != 1 {
+			continue
+		}
+		v.Warned = today
+		db.SetStreak(v)
+
+		channel, err := state.Session.UserChannelCreate(v.UserID)
+		if err != nil {
+			continue
+		}
+		state.SendReply(channel.ID, fmt.Sprintf("Your %v day streak ends at midnight, say something!", v.Current))
+	}
+}
+
+// StreakCmd Show a user's current and best streak
+func StreakCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+	user := message.Author
+	if len(message.Mentions) > 0 {
+		user = message.Mentions[0]
+	}
+
+	state.SendReply(message.ChannelID, describeStreak(user, db.GetStreak(user.ID, guildID), guildID))
+}
+
+// the streak is only still going if freezes can cover the days since
+func currentStreak(streak db.Streak, guildID string) int {
+	today := time.Now().In(Location(guildID, streak.UserID)).Format(dayFormat)
+	if missed := daysBetween(streak.LastDay, today) - 1; streak.LastDay == "" || missed > streak.Freezes {
+		return 0
+	}
+	return streak.Current
+}
+
+func describeStreak(user *discordgo.User, streak db.Streak, guildID string) string {
+	reply := fmt.Sprintf("%v is on a %v day streak (best %v)", user.Username, currentStreak(streak, guildID), streak.Best)
+	if streak.Freezes > 0 {
+		reply += fmt.Sprintf(", %v freezes left", streak.Freezes)
+	}
+	return reply
+}
@@ -0,0 +1,64 @@
+package rate
+
+import (
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+)
+
+func TestAdvanceStreak(t *testing.T) {
+	cases := []struct {
+		name     string
+		streak   db.Streak
+		today    string
+		want     db.Streak
+		extended bool
+	}{
+		{"first day", db.Streak{}, "2026-09-01",
+			db.Streak{Current: 1, Best: 1, LastDay: "2026-09-01"}, true},
+		{"same day", db.Streak{Current: 4, Best: 4, LastDay: "2026-09-01"}, "2026-09-01",
+			db.Streak{Current: 4, Best: 4, LastDay: "2026-09-01"}, false},
+		{"next day", db.Streak{Current: 4, Best: 9, LastDay: "2026-09-30"}, "2026-10-01",
+			db.Streak{Current: 5, Best: 9, LastDay: "2026-10-01"}, true},
+		{"missed a day", db.Streak{Current: 4, Best: 4, LastDay: "2026-09-01"}, "2026-09-03",
+			db.Streak{Current: 1, Best: 4, LastDay: "2026-09-03"}, true},
+		{"frozen", db.Streak{Current: 4, Best: 4, LastDay: "2026-09-01", Freezes: 2}, "2026-09-04",
+			db.Streak{Current: 5, Best: 5, LastDay: "2026-09-04"}, true},
+		{"not enough freezes", db.Streak{Current: 4, Best: 4, LastDay: "2026-09-01", Freezes: 1}, "2026-09-04",
+			db.Streak{Current: 1, Best: 4, LastDay: "2026-09-04", Freezes: 1}, true},
+		{"earned a freeze", db.Streak{Current: 6, Best: 6, LastDay: "2026-09-06"}, "2026-09-07",
+			db.Streak{Current: 7, Best: 7, LastDay: "2026-09-07", Freezes: 1}, true},
+		{"freezes full", db.Streak{Current: 13, Best: 13, LastDay: "2026-09-13", Freezes: 2}, "2026-09-14",
+			db.Streak{Current: 14, Best: 14, LastDay: "2026-09-14", Freezes: 2}, true},
+	}
+
+	for _, v := range cases {
+		got, extended := advanceStreak(v.streak, v.today, 7, 2)
+		if got != v.want || extended != v.extended {
+			t.Errorf("%v: wanted %+v %v, got %+v %v", v.name, v.want, v.extended, got, extended)
+		}
+	}
+}
+
+func TestStreakBonus(t *testing.T) {
+	cases := []struct {
+		curve string
+		days  int
+		want  int
+	}{
+		{"none", 20, 0},
+		{"linear", 1, 0},
+		{"linear", 4, 6},
+		{"linear", 30, 10},
+		{"log", 1, 0},
+		{"log", 8, 6},
+		{"step", 6, 0},
+		{"step", 14, 4},
+	}
+
+	for _, v := range cases {
+		if got := streakBonus(v.curve, 2, 10, v.days); got != v.want {
+			t.Errorf("%v day %v: wanted %v, got %v", v.curve, v.days, v.want, got)
+		}
+	}
+}
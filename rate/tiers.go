@@ -0,0 +1,362 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// who gets a tier
+const (
+	// the top Value users
+	tierRank = "rank"
+	// the top Value percent of users
+	tierPercentile = "percentile"
+	// the top users holding Value percent of all respec
+	tierShare = "share"
+	// users with at least Value respec
+	tierThreshold = "threshold"
+	// users with less than Value respec
+	tierBelow = "below"
+)
+
+type rankedUser struct {
+	id     string
+	respec int
+}
+
+// the leaderboard rank, percentile and share tiers are taken from
+type leaderboard interface {
+	Top(count int) []score.Entry
+	Share(percent int) []score.Entry
+	Count() int
+}
+
+// the score cache, everyone with respec in rank order
+type scoreBoard struct{}
+
+func (scoreBoard) Top(count int) []score.Entry     { return score.Top(count) }
+func (scoreBoard) Share(percent int) []score.Entry { return score.Share(percent) }
+func (scoreBoard) Count() int                      { return score.Count() }
+
+var (
+	defaultTiers = []db.Tier{
+		{Name: "Supreme Ruler", Kind: tierRank, Value: 1},
+		{Name: "Ruling Class", Kind: tierShare, Value: 50},
+		{Name: "Losers", Kind: tierBelow, Value: 0},
+	}
+	tierKinds = map[string]bool{tierRank: true, tierPercentile: true, tierShare: true, tierThreshold: true, tierBelow: true}
+
+	guildTierList map[string][]db.Tier
+	tierMux       sync.Mutex
+)
+
+func init() {
+	guildTierList = make(map[string][]db.Tier)
+
+	config.Register("tier.defaults", "1", "use the Supreme Ruler, Ruling Class and Losers tiers until the server adds its own (1 or 0)")
+}
+
+func guildTiers(guildID string) []db.Tier {
+	tierMux.Lock()
+	defer tierMux.Unlock()
+
+	tiers, ok := guildTierList[guildID]
+	if !ok {
+		tiers = db.GetTiers(guildID)
+		guildTierList[guildID] = tiers
+	}
+	if len(tiers) == 0 && config.Int(guildID, "tier.defaults") == 1 {
+		return defaultTiers
+	}
+	return tiers
+}
+
+func resetGuildTiers(guildID string) {
+	tierMux.Lock()
+	delete(guildTierList, guildID)
+	tierMux.Unlock()
+}
+
+// work out who belongs in each tier and change only the roles that are wrong, use reconcileNow or scheduleReconcile instead
+func syncTiers(guildID string) error {
+	guild, err := state.Session.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	tiers := guildTiers(guildID)
+	if len(tiers) == 0 {
+		return nil
+	}
+	roleIDs, err := tierRoles(guild, tiers)
+	if err != nil {
+		return err
+	}
+
+	var users []rankedUser
+	for _, v := range guild.Members {
+		if !v.User.Bot {
+			users = append(users, rankedUser{v.User.ID, score.GetByID(v.User.ID)})
+		}
+	}
+	members := tierMembers(tiers, scoreBoard{}, users)
+
+	applyRoleChanges(guildID, diffTierRoles(guild.Members, tiers, roleIDs, members))
+	return nil
+}
+
+// the role for every tier, roles that don't exist yet get made
+func tierRoles(guild *discordgo.Guild, tiers []db.Tier) (roleIDs []string, err error) {
+	roles := guild.Roles
+	for _, tier := range tiers {
+		var role *discordgo.Role
+		for _, v := range roles {
+			if v.Name == tier.Name {
+				role = v
+				break
+			}
+		}
+		if role == nil {
+			if role, err = createTierRole(guild.ID, tier); err != nil {
+				return nil, err
+			}
+			roles = append(roles, role)
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+	return
+}
+
+func createTierRole(guildID string, tier db.Tier) (*discordgo.Role, error) {
+	role, err := state.Session.GuildRoleCreate(guildID)
+	if err != nil {
+		return nil, err
+	}
+	logging.Log(fmt.Sprintf("created role %v in %v", tier.Name, guildID))
+	return state.Session.GuildRoleEdit(guildID, role.ID, tier.Name, tier.Color, tier.Hoist, role.Permissions, false)
+}
+
+func memberHasRole(member *discordgo.Member, roleID string) bool {
+	for _, v := range member.Roles {
+		if v == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// most respec first
+func sortRanked(users []rankedUser) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].respec == users[j].respec {
+			return users[i].id < users[j].id
+		}
+		return users[i].respec > users[j].respec
+	})
+}
+
+// who belongs in each tier, rank, percentile and share come off the leaderboard and
+// can include users from other servers, thresholds are checked for the guild's users
+func tierMembers(tiers []db.Tier, board leaderboard, users []rankedUser) (members []map[string]bool) {
+	for _, tier := range tiers {
+		in := make(map[string]bool)
+		switch tier.Kind {
+		case tierRank:
+			for _, v := range board.Top(tier.Value) {
+				in[v.ID] = true
+			}
+		case tierPercentile:
+			count := int(math.Ceil(float64(board.Count()) * float64(tier.Value) / 100))
+			for _, v := range board.Top(count) {
+				in[v.ID] = true
+			}
+		case tierShare:
+			for _, v := range board.Share(tier.Value) {
+				in[v.ID] = true
+			}
+		case tierThreshold:
+			for _, v := range users {
+				if v.respec >= tier.Value {
+					in[v.id] = true
+				}
+			}
+		case tierBelow:
+			for _, v := range users {
+				if v.respec < tier.Value {
+					in[v.id] = true
+				}
+			}
+		}
+		members = append(members, in)
+	}
+	return
+}
+
+func describeTier(tier db.Tier) string {
+	switch tier.Kind {
+	case tierRank:
+		return fmt.Sprintf("top %v", tier.Value)
+	case tierPercentile:
+		return fmt.Sprintf("top %v%% of users", tier.Value)
+	case tierShare:
+		return fmt.Sprintf("top %v%% of respec", tier.Value)
+	case tierThreshold:
+		return fmt.Sprintf("%v or more respec", tier.Value)
+	case tierBelow:
+		return fmt.Sprintf("less than %v respec", tier.Value)
+	}
+	return tier.Kind
+}
+
+// TierCmd Handle the tier command to set up the guild's rank roles
+func TierCmd(message *discordgo.Message, args []string) {
+	if len(args) < 2 || args[1] == "help" {
+		reply := "```"
+		reply += "'tier help' - display this message\n"
+		reply += "'tier list' - display this server's tiers\n"
+		reply += "'tier add [kind] [value] [#color] [hoist] [role name]' - add a tier, color and hoist are optional\n"
+		reply += "'tier remove [id]' - stop giving out a tier, the role is left alone\n"
+		reply += "'tier sync' - fix everyone's tier roles now\n"
+		reply += "Kinds: rank (top N users), percentile (top N% of users), share (top users with N% of all respec),\n"
+		reply += "threshold (N or more respec), below (less than N respec)\n"
+		reply += "(rank, percentile and share go by the whole leaderboard, like 'stats')\n"
+		reply += "(Only server managers can change tiers)"
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	cmd := strings.ToLower(args[1])
+	if cmd == "list" {
+		tiers := guildTiers(guildID)
+		if len(tiers) == 0 {
+			state.SendReply(message.ChannelID, "This server has no tiers")
+			return
+		}
+		reply := "```\n"
+		for _, v := range tiers {
+			reply += fmt.Sprintf("%v. %v - %v", v.ID, v.Name, describeTier(v))
+			if v.Color != 0 {
+				reply += fmt.Sprintf(" #%06x", v.Color)
+			}
+			if v.Hoist {
+				reply += " hoisted"
+			}
+			reply += "\n"
+		}
+		if tiers[0].ID == 0 {
+			reply += "(default tiers, adding a tier keeps them)\n"
+		}
+		reply += "```"
+		state.SendReply(message.ChannelID, reply)
+		return
+	}
+
+	if cmd != "add" && cmd != "remove" && cmd != "sync" {
+		state.SendReply(message.ChannelID, "Not a valid tier command, use `tier help`")
+		return
+	}
+	if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+		state.SendReply(message.ChannelID, "You can't do that")
+		return
+	}
+
+	switch cmd {
+	case "add":
+		tier, err := parseTier(guildID, args[2:])
+		if err != nil {
+			state.SendReply(message.ChannelID, err.Error())
+			return
+		}
+		// keep the defaults the server was already using
+		if len(db.GetTiers(guildID)) == 0 && config.Int(guildID, "tier.defaults") == 1 {
+			for _, v := range defaultTiers {
+				v.GuildID = guildID
+				db.AddTier(&v)
+			}
+		}
+		db.AddTier(&tier)
+		applyTierStyle(guildID, tier)
+		state.SendReply(message.ChannelID, fmt.Sprintf("Added tier %v. %v - %v", tier.ID, tier.Name, describeTier(tier)))
+
+	case "remove":
+		if len(args) < 3 {
+			state.SendReply(message.ChannelID, "Usage: `tier remove [id]`")
+			return
+		}
+		id, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil || !db.RemoveTier(guildID, id) {
+			state.SendReply(message.ChannelID, "No tier with that id")
+			return
+		}
+		state.SendReply(message.ChannelID, "Removed tier, the role is still there if you want to delete it")
+	}
+
+	resetGuildTiers(guildID)
+	if err := reconcileNow(guildID); err != nil {
+		state.SendReply(message.ChannelID, fmt.Sprintf("Couldn't sync roles: %v", err))
+	}
+}
+
+// kind value [#color] [hoist] name
+func parseTier(guildID string, args []string) (tier db.Tier, err error) {
+	if len(args) < 3 {
+		return tier, fmt.Errorf("Usage: `tier add [kind] [value] [#color] [hoist] [role name]`")
+	}
+
+	tier.GuildID = guildID
+	tier.Kind = strings.ToLower(args[0])
+	if !tierKinds[tier.Kind] {
+		return tier, fmt.Errorf("Invalid kind, use rank, percentile, share, threshold or below")
+	}
+	if tier.Value, err = strconv.Atoi(args[1]); err != nil {
+		return tier, fmt.Errorf("Invalid value")
+	}
+
+	args = args[2:]
+	for len(args) > 1 {
+		if strings.HasPrefix(args[0], "#") {
+			color, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 16, 32)
+			if err != nil {
+				return tier, fmt.Errorf("Invalid color, use #rrggbb")
+			}
+			tier.Color = int(color)
+		} else if strings.ToLower(args[0]) == "hoist" {
+			tier.Hoist = true
+		} else {
+			break
+		}
+		args = args[1:]
+	}
+	tier.Name = strings.Join(args, " ")
+	return tier, nil
+}
+
+// make a role that already exists look the way the tier says
+func applyTierStyle(guildID string, tier db.Tier) {
+	guild, err := state.Session.Guild(guildID)
+	if err != nil {
+		return
+	}
+	for _, v := range guild.Roles {
+		if v.Name == tier.Name && (v.Color != tier.Color || v.Hoist != tier.Hoist) {
+			state.Session.GuildRoleEdit(guildID, v.ID, v.Name, tier.Color, tier.Hoist, v.Permissions, v.Mentionable)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/score"
+)
+
+// a leaderboard already in rank order
+type testBoard []score.Entry
+
+func (b testBoard) Top(count int) []score.Entry {
+	if count > len(b) {
+		count = len(b)
+	}
+	return b[:count]
+}
+
+func (b testBoard) Share(percent int) (entries []score.Entry) {
+	total := 0
+	for _, v := range b {
+		total += v.Respec
+	}
+	if total <= 0 {
+		return nil
+	}
+	before := 0
+	for _, v := range b {
+		if before*100 > percent*total {
+			break
+		}
+		before += v.Respec
+		entries = append(entries, v)
+	}
+	return
+}
+
+func (b testBoard) Count() int {
+	return len(b)
+}
+
+func TestTierMembers(t *testing.T) {
+	users := []rankedUser{{"d", -5}, {"a", 40}, {"c", 10}, {"b", 35}, {"e", 0}}
+	sortRanked(users)
+	var board testBoard
+	for _, v := range users {
+		board = append(board, score.Entry{ID: v.id, Respec: v.respec})
+	}
+
+	tiers := append(defaultTiers,
+		db.Tier{Kind: tierPercentile, Value: 50},
+		db.Tier{Kind: tierThreshold, Value: 10},
+		db.Tier{Kind: tierRank, Value: 10},
+	)
+	want := []map[string]bool{
+		{"a": true},
+		{"a": true, "b": true},
+		{"d": true},
+		{"a": true, "b": true, "c": true},
+		{"a": true, "b": true, "c": true},
+		{"a": true, "b": true, "c": true, "d": true, "e": true},
+	}
+
+	if got := tierMembers(tiers, board, users); !reflect.DeepEqual(got, want) {
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+}
+
+func TestParseTier(t *testing.T) {
+	tier, err := parseTier("1", []string{"rank", "3", "#ff8800", "hoist", "Big", "Boys"})
+	want := db.Tier{GuildID: "1", Name: "Big Boys", Kind: tierRank, Value: 3, Color: 0xff8800, Hoist: true}
+	if err != nil || tier != want {
+		t.Errorf("Wanted %+v, got %+v %v", want, tier, err)
+	}
+
+	if _, err = parseTier("1", []string{"best", "3", "Name"}); err == nil {
+		t.Errorf("Invalid kind was accepted")
+	}
+	if _, err = parseTier("1", []string{"rank", "3"}); err == nil {
+		t.Errorf("Missing name was accepted")
+	}
+}
@@ -0,0 +1,153 @@
+package rate
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// users' timezones, nil if they haven't picked one
+	userLocations map[string]*time.Location
+	locationMux   sync.Mutex
+)
+
+func init() {
+	userLocations = make(map[string]*time.Location)
+
+	// bets always ran on Vancouver time, servers that never set a timezone stay on it
+	config.RegisterCheck("timezone", "America/Vancouver", "the server's timezone as Area/City, days start at midnight here", checkTimezone)
+	config.RegisterCheck("quiet.start", "0", "hour of the day quiet hours start, respec doesn't decay during them", checkHour)
+	config.RegisterCheck("quiet.end", "0", "hour of the day quiet hours end, the same as quiet.start for no quiet hours", checkHour)
+}
+
+func checkTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("I don't know the timezone %v, use a name like America/Vancouver", name)
+	}
+	return nil
+}
+
+func checkHour(value string) error {
+	if hour, err := strconv.Atoi(value); err != nil || hour < 0 || hour > 23 {
+		return fmt.Errorf("Hours go from 0 to 23")
+	}
+	return nil
+}
+
+// the guild's timezone, UTC if it's broken
+func guildLocation(guildID string) *time.Location {
+	location, err := time.LoadLocation(config.String(guildID, "timezone"))
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// the timezone a user picked, nil if they didn't
+func userLocation(userID string) *time.Location {
+	locationMux.Lock()
+	defer locationMux.Unlock()
+
+	if location, ok := userLocations[userID]; ok {
+		return location
+	}
+
+	var location *time.Location
+	if name, ok := db.GetUserTimezone(userID); ok {
+		location, _ = time.LoadLocation(name)
+	}
+	userLocations[userID] = location
+	return location
+}
+
+// Location The user's timezone if they picked one, otherwise the guild's
+func Location(guildID, userID string) *time.Location {
+	if userID != "" {
+		if location := userLocation(userID); location != nil {
+			return location
+		}
+	}
+	return guildLocation(guildID)
+}
+
+// LocalTime Format a time for someone to read in their timezone, the zone is on the end
+func LocalTime(t time.Time, location *time.Location, layout string) string {
+	return t.In(location).Format(layout + " MST")
+}
+
+// if the hour is in quiet hours that start at one hour and end at another, they can go past midnight
+func inQuietHours(hour, start, end int) bool {
+	if start == end {
+		return false
+	}
+	if start < end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+func guildQuiet(guildID string, now time.Time) bool {
+	return quietAt(now, guildLocation(guildID), config.Int(guildID, "quiet.start"), config.Int(guildID, "quiet.end"))
+}
+
+// quiet hours go by the clock where the guild is
+func quietAt(now time.Time, location *time.Location, start, end int) bool {
+	return inQuietHours(now.In(location).Hour(), start, end)
+}
+
+// TimezoneCmd Show or pick your timezone, or the server's for admins
+func TimezoneCmd(message *discordgo.Message, args []string) {
+	guildID, err := state.GuildID(message.ChannelID)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case len(args) < 2:
+		location := Location(guildID, message.Author.ID)
+		reply := fmt.Sprintf("Your timezone is %v, it's %v there", location, LocalTime(time.Now(), location, "15:04"))
+		if userLocation(message.Author.ID) == nil {
+			reply += ". That's the server's, pick your own with `timezone Area/City`"
+		}
+		state.SendReply(message.ChannelID, reply)
+	case args[1] == "server":
+		if len(args) < 3 {
+			state.SendReply(message.ChannelID, fmt.Sprintf("The server's timezone is %v", guildLocation(guildID)))
+			return
+		}
+		if !state.IsAdmin(message.ChannelID, message.Author.ID) {
+			state.SendReply(message.ChannelID, "You can't do that")
+			return
+		}
+		if err := config.Set(guildID, "timezone", args[2]); err != nil {
+			state.SendReply(message.ChannelID, err.Error())
+			return
+		}
+		state.SendReply(message.ChannelID, fmt.Sprintf("The server's timezone is now %v", args[2]))
+	case args[1] == "reset":
+		setUserTimezone(message.Author.ID, "")
+		state.SendReply(message.ChannelID, "You're back on the server's timezone")
+	default:
+		if err := checkTimezone(args[1]); err != nil {
+			state.SendReply(message.ChannelID, err.Error())
+			return
+		}
+		setUserTimezone(message.Author.ID, args[1])
+		state.SendReply(message.ChannelID, fmt.Sprintf("Your timezone is now %v", args[1]))
+	}
+}
+
+func setUserTimezone(userID, name string) {
+	locationMux.Lock()
+	defer locationMux.Unlock()
+
+	db.SetUserTimezone(userID, name)
+	delete(userLocations, userID)
+}
@@ -0,0 +1,39 @@
+package rate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInQuietHours(t *testing.T) {
+	cases := []struct {
+		hour, start, end int
+		want             bool
+	}{
+		{3, 0, 0, false},
+		{3, 1, 6, true},
+		{6, 1, 6, false},
+		{0, 1, 6, false},
+		{23, 22, 7, true},
+		{2, 22, 7, true},
+		{7, 22, 7, false},
+		{12, 22, 7, false},
+	}
+
+	for _, v := range cases {
+		if got := inQuietHours(v.hour, v.start, v.end); got != v.want {
+			t.Errorf("%v in %v-%v: wanted %v, got %v", v.hour, v.start, v.end, v.want, got)
+		}
+	}
+}
+
+func TestLocalTime(t *testing.T) {
+	location, err := time.LoadLocation("America/Vancouver")
+	if err != nil {
+		t.Skip(err)
+	}
+	when := time.Date(2018, time.January, 2, 3, 4, 5, 0, time.UTC)
+	if got, want := LocalTime(when, location, "2006-01-02 15:04"), "2018-01-01 19:04 PST"; got != want {
+		t.Errorf("Wanted %v, got %v", want, got)
+	}
+}
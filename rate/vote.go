@@ -0,0 +1,134 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/config"
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/logging"
+	"github.com/Jaggernaut555/respecbot/score"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// one or more mentions and then ++ or --
+var (
+	votePattern        = regexp.MustCompile(`((?:<@!?\d+>\s*)+)(\+\+|--)`)
+	voteMentionPattern = regexp.MustCompile(`<@!?(\d+)>`)
+)
+
+// a ++ or -- someone wrote for someone else
+type inlineVote struct {
+	receiverID string
+	up         bool
+	reason     string
+}
+
+func init() {
+	config.Register("karma.value", "1", "respec a ++ or -- is worth from a user with barely any respec")
+	config.Register("karma.max", "4", "most respec a ++ or -- is worth, users with more respec get closer to it")
+	config.Register("karma.daily", "10", "++ and -- each user can give in a day")
+}
+
+// every vote in the message, the reason is whatever's written after it up to the next vote
+func parseVotes(content string) (votes []inlineVote) {
+	matches := votePattern.FindAllStringSubmatchIndex(content, -1)
+	seen := make(map[string]bool)
+	for i, match := range matches {
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		reason := strings.TrimSpace(content[match[1]:end])
+		if runes := []rune(reason); len(runes) > 200 {
+			reason = string(runes[:200])
+		}
+		up := content[match[4]:match[5]] == "++"
+
+		for _, mention := range voteMentionPattern.FindAllStringSubmatch(content[match[2]:match[3]], -1) {
+			if seen[mention[1]] {
+				continue
+			}
+			seen[mention[1]] = true
+			votes = append(votes, inlineVote{receiverID: mention[1], up: up, reason: reason})
+		}
+	}
+	return
+}
+
+// respected users' votes are worth more, one more for every power of ten
+func voteWeight(base, max, giverRespec int) int {
+	weight := base
+	if giverRespec > 1 {
+		weight += int(math.Log10(float64(giverRespec)))
+	}
+	if weight > max {
+		weight = max
+	}
+	return weight
+}
+
+// karma bot style ++ and --, they don't count as mentions too
+func respecVotes(guildID string, author *discordgo.User, message *discordgo.Message) (voted map[string]bool) {
+	voted = make(map[string]bool)
+	votes := parseVotes(parseContent(message.Content).prose())
+	if len(votes) == 0 {
+		return
+	}
+
+	users := make(map[string]*discordgo.User)
+	for _, v := range message.Mentions {
+		users[v.ID] = v
+	}
+
+	timeStamp, _ := message.Timestamp.Parse()
+	budget := config.Int(guildID, "karma.daily") - db.CountVotesSince(guildID, author.ID, timeStamp.Add(-day))
+	weight := voteWeight(config.Int(guildID, "karma.value"), config.Int(guildID, "karma.max"), score.GetByID(author.ID))
+	outOfVotes := false
+
+	for _, v := range votes {
+		receiver, ok := users[v.receiverID]
+		if !ok || receiver.Bot {
+			continue
+		}
+		voted[receiver.ID] = true
+		if receiver.ID == author.ID {
+			logging.Log(fmt.Sprintf("%v voted for self", author))
+			continue
+		}
+		if budget <= 0 {
+			outOfVotes = true
+			continue
+		}
+		budget--
+
+		respec := weight
+		if !v.up {
+			respec = -weight
+		}
+		logging.Log(fmt.Sprintf("%v voted %+d for %v: %v", author, respec, receiver, v.reason))
+		AddRespecReason(guildID, receiver, respec, reasonVote, message.ID)
+		db.AddVote(db.Vote{GuildID: guildID, GiverID: author.ID, ReceiverID: receiver.ID, MessageID: message.ID, Respec: respec, Reason: v.reason, Time: timeStamp})
+	}
+
+	if outOfVotes {
+		state.SendReply(message.ChannelID, fmt.Sprintf("%v, you're out of ++ and -- for today", author.Username))
+	}
+	return
+}
+
+// the latest votes a user got with why, for their profile
+func describeVotes(userID string, location *time.Location) (lines []string) {
+	for _, v := range db.GetVotesReceived(userID, 3) {
+		line := fmt.Sprintf("%+d from %v (%v)", v.Respec, username(v.GiverID), v.Time.In(location).Format(dayFormat))
+		if v.Reason != "" {
+			line += ": " + v.Reason
+		}
+		lines = append(lines, line)
+	}
+	return
+}
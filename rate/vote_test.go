@@ -0,0 +1,53 @@
+package rate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVotes(t *testing.T) {
+	cases := []struct {
+		content string
+		want    []inlineVote
+	}{
+		{"<@1> ++", []inlineVote{{"1", true, ""}}},
+		{"<@!1>-- for that take", []inlineVote{{"1", false, "for that take"}}},
+		{"<@1> ++ great answer <@2> -- rude", []inlineVote{
+			{"1", true, "great answer"},
+			{"2", false, "rude"},
+		}},
+		{"<@1> <@2> ++ thanks both", []inlineVote{
+			{"1", true, "thanks both"},
+			{"2", true, "thanks both"},
+		}},
+		{"<@1> ++ <@1> ++", []inlineVote{{"1", true, ""}}},
+		{"<@&1> ++ roles don't count", nil},
+		{"c++ is fine, ask <@1>", nil},
+		{"hey <@1>", nil},
+	}
+
+	for _, v := range cases {
+		if got := parseVotes(v.content); !reflect.DeepEqual(got, v.want) {
+			t.Errorf("%q: wanted %v, got %v", v.content, v.want, got)
+		}
+	}
+}
+
+func TestVoteWeight(t *testing.T) {
+	cases := []struct {
+		respec, want int
+	}{
+		{-50, 1},
+		{0, 1},
+		{9, 1},
+		{10, 2},
+		{150, 3},
+		{1000000, 4},
+	}
+
+	for _, v := range cases {
+		if got := voteWeight(1, 4, v.respec); got != v.want {
+			t.Errorf("%v respec: wanted %v, got %v", v.respec, v.want, got)
+		}
+	}
+}
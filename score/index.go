@@ -0,0 +1,198 @@
+package score
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Entry A user's respec
+type Entry struct {
+	ID       string
+	Username string
+	Respec   int
+}
+
+// treap ordered by most respec first, each node knows the size and respec of everything under it
+type node struct {
+	entry     Entry
+	priority  uint32
+	left      *node
+	right     *node
+	size      int
+	sum       int
+	maxPrefix int
+}
+
+type index struct {
+	root *node
+	rand *rand.Rand
+}
+
+func newIndex() *index {
+	return &index{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// most respec first, ties go by ID so every entry has one spot
+func less(a, b Entry) bool {
+	if a.Respec == b.Respec {
+		return a.ID < b.ID
+	}
+	return a.Respec > b.Respec
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func sum(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.sum
+}
+
+// the most respec any run of the first users in the subtree adds up to, 0 for no users
+func maxPrefix(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.maxPrefix
+}
+
+func (n *node) update() {
+	n.size = size(n.left) + 1 + size(n.right)
+	n.sum = sum(n.left) + n.entry.Respec + sum(n.right)
+
+	n.maxPrefix = maxPrefix(n.left)
+	if through := sum(n.left) + n.entry.Respec; through > n.maxPrefix {
+		n.maxPrefix = through
+	}
+	if through := sum(n.left) + n.entry.Respec + maxPrefix(n.right); through > n.maxPrefix {
+		n.maxPrefix = through
+	}
+}
+
+// everything before e and everything from e on
+func split(n *node, e Entry) (*node, *node) {
+	if n == nil {
+		return nil, nil
+	}
+	if less(n.entry, e) {
+		l, r := split(n.right, e)
+		n.right = l
+		n.update()
+		return n, r
+	}
+	l, r := split(n.left, e)
+	n.left = r
+	n.update()
+	return l, n
+}
+
+func merge(l, r *node) *node {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if l.priority > r.priority {
+		l.right = merge(l.right, r)
+		l.update()
+		return l
+	}
+	r.left = merge(l, r.left)
+	r.update()
+	return r
+}
+
+func (idx *index) insert(e Entry) {
+	n := &node{entry: e, priority: idx.rand.Uint32()}
+	n.update()
+	l, r := split(idx.root, e)
+	idx.root = merge(merge(l, n), r)
+}
+
+// e has to match what was inserted
+func (idx *index) remove(e Entry) {
+	idx.root = remove(idx.root, e)
+}
+
+func remove(n *node, e Entry) *node {
+	if n == nil {
+		return nil
+	}
+	if n.entry.ID == e.ID {
+		return merge(n.left, n.right)
+	}
+	if less(e, n.entry) {
+		n.left = remove(n.left, e)
+	} else {
+		n.right = remove(n.right, e)
+	}
+	n.update()
+	return n
+}
+
+func (idx *index) len() int {
+	return size(idx.root)
+}
+
+func (idx *index) total() int {
+	return sum(idx.root)
+}
+
+// how many entries come before e
+func (idx *index) rank(e Entry) (rank int) {
+	n := idx.root
+	for n != nil {
+		if less(n.entry, e) {
+			rank += size(n.left) + 1
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+	return
+}
+
+// the first count entries
+func (idx *index) top(count int) (entries []Entry) {
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil || len(entries) >= count {
+			return
+		}
+		walk(n.left)
+		if len(entries) < count {
+			entries = append(entries, n.entry)
+		}
+		walk(n.right)
+	}
+	walk(idx.root)
+	return
+}
+
+// how many of the first entries it takes for their respec to add up to more than limit, all of them if it never does
+func (idx *index) countOver(limit int) (count int) {
+	if maxPrefix(idx.root) <= limit {
+		return idx.len()
+	}
+
+	n, before := idx.root, 0
+	for n != nil {
+		if before+maxPrefix(n.left) > limit {
+			n = n.left
+		} else if before+sum(n.left)+n.entry.Respec > limit {
+			return count + size(n.left) + 1
+		} else {
+			count += size(n.left) + 1
+			before += sum(n.left) + n.entry.Respec
+			n = n.right
+		}
+	}
+	return
+}
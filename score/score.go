@@ -0,0 +1,304 @@
+package score
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jaggernaut555/respecbot/db"
+	"github.com/Jaggernaut555/respecbot/state"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Cache Everyone's respec kept in memory and in rank order, changes are written through to the database
+type Cache struct {
+	mux         sync.RWMutex
+	users       map[string]Entry
+	index       *index
+	lastMessage map[string]time.Time
+	write       func(e Entry, respec int, isNew bool)
+}
+
+var cache *Cache
+
+// ErrBalance The giver doesn't have enough respec
+var ErrBalance = fmt.Errorf("Not enough respec")
+
+// ErrRefused The database wouldn't take the change
+var ErrRefused = fmt.Errorf("Change refused")
+
+func init() {
+	cache = newCache(func(e Entry, respec int, isNew bool) {
+		if isNew {
+			db.AddUser(e.ID, e.Username, respec)
+		} else {
+			db.GainRespecByID(e.ID, respec)
+		}
+	})
+}
+
+// write is called with the lock held so the database sees changes in the same order as the cache
+func newCache(write func(e Entry, respec int, isNew bool)) *Cache {
+	return &Cache{
+		users:       make(map[string]Entry),
+		index:       newIndex(),
+		lastMessage: make(map[string]time.Time),
+		write:       write,
+	}
+}
+
+func (c *Cache) load(users []db.User) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.users = make(map[string]Entry)
+	c.index = newIndex()
+	c.lastMessage = make(map[string]time.Time)
+	for _, v := range users {
+		e := Entry{ID: v.ID, Username: v.Username, Respec: v.Respec}
+		c.users[e.ID] = e
+		c.index.insert(e)
+	}
+}
+
+func (c *Cache) lookup(userID string) (Entry, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	e, ok := c.users[userID]
+	return e, ok
+}
+
+func (c *Cache) get(userID string) int {
+	e, _ := c.lookup(userID)
+	return e.Respec
+}
+
+// username is only used for users the cache hasn't seen yet
+func (c *Cache) add(userID, username string, respec int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	e, ok := c.users[userID]
+	if ok {
+		c.index.remove(e)
+	} else {
+		e = Entry{ID: userID, Username: username}
+	}
+	if c.write != nil {
+		c.write(e, respec, !ok)
+	}
+	e.Respec += respec
+	c.users[userID] = e
+	c.index.insert(e)
+}
+
+// move respec between two users, write has to save both sides at once
+func (c *Cache) transfer(fromID, toID, toUsername string, amount, minimum int, write func(toIsNew bool)) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	from, ok := c.users[fromID]
+	if !ok || from.Respec-amount < minimum {
+		return ErrBalance
+	}
+	to, ok := c.users[toID]
+	if !ok {
+		to = Entry{ID: toID, Username: toUsername}
+	}
+
+	if write != nil {
+		write(!ok)
+	}
+
+	c.index.remove(from)
+	from.Respec -= amount
+	c.users[fromID] = from
+	c.index.insert(from)
+
+	if ok {
+		c.index.remove(to)
+	}
+	to.Respec += amount
+	c.users[toID] = to
+	c.index.insert(to)
+	return nil
+}
+
+// take respec from a user as long as they have it, nothing changes if write says no
+func (c *Cache) spend(userID string, amount int, write func() bool) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	e, ok := c.users[userID]
+	if !ok || e.Respec < amount {
+		return ErrBalance
+	}
+	if write != nil && !write() {
+		return ErrRefused
+	}
+
+	c.index.remove(e)
+	e.Respec -= amount
+	c.users[userID] = e
+	c.index.insert(e)
+	return nil
+}
+
+func (c *Cache) count() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.index.len()
+}
+
+func (c *Cache) total() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.index.total()
+}
+
+func (c *Cache) rank(userID string) (rank int, ok bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	e, ok := c.users[userID]
+	if !ok {
+		return 0, false
+	}
+	return c.index.rank(e) + 1, true
+}
+
+func (c *Cache) top(count int) []Entry {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.index.top(count)
+}
+
+func (c *Cache) all() []Entry {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.index.top(c.index.len())
+}
+
+func (c *Cache) share(percent int) []Entry {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	total := c.index.total()
+	if total <= 0 {
+		return nil
+	}
+	// same as adding up percentages, without the rounding
+	return c.index.top(c.index.countOver(percent * total / 100))
+}
+
+// Load Fill the cache from the database, anything cached before is dropped
+func Load() {
+	cache.load(db.GetUsers())
+}
+
+// Get A user's respec
+func Get(user *discordgo.User) int {
+	return cache.get(user.ID)
+}
+
+// GetByID A user's respec
+func GetByID(userID string) int {
+	return cache.get(userID)
+}
+
+// Lookup A user's entry, if they have one
+func Lookup(userID string) (Entry, bool) {
+	return cache.lookup(userID)
+}
+
+// Add Give a user respec, new users are added
+func Add(user *discordgo.User, respec int) {
+	cache.add(user.ID, user.String(), respec)
+}
+
+// AddByID Give a user respec by ID, users the cache hasn't seen are looked up for their name
+func AddByID(userID string, respec int) {
+	username := ""
+	if _, ok := cache.lookup(userID); !ok {
+		username = lookupUsername(userID)
+	}
+	cache.add(userID, username, respec)
+}
+
+// the user's name on discord, their ID if discord doesn't know them
+func lookupUsername(userID string) string {
+	if state.Session != nil {
+		if user, err := state.Session.User(userID); err == nil {
+			return user.String()
+		}
+	}
+	return userID
+}
+
+// Transfer Move respec from one user to another if the giver keeps at least minimum, write saves it all at once
+func Transfer(fromID string, to *discordgo.User, amount, minimum int, write func(toIsNew bool)) error {
+	return cache.transfer(fromID, to.ID, to.String(), amount, minimum, write)
+}
+
+// Spend Take respec from a user who has enough of it, write saves it and can refuse
+func Spend(userID string, amount int, write func() bool) error {
+	return cache.spend(userID, amount, write)
+}
+
+// Count How many users have respec
+func Count() int {
+	return cache.count()
+}
+
+// Total All the respec there is
+func Total() int {
+	return cache.total()
+}
+
+// Rank Where a user is on the leaderboard, starting at 1
+func Rank(userID string) (int, bool) {
+	return cache.rank(userID)
+}
+
+// Top The users with the most respec, most first
+func Top(count int) []Entry {
+	return cache.top(count)
+}
+
+// All Every user, most respec first
+func All() []Entry {
+	return cache.all()
+}
+
+// Share The top users holding the given percent of all respec, it includes the user who takes it over
+func Share(percent int) []Entry {
+	return cache.share(percent)
+}
+
+// LastMessage When the user last sent a message, key is what messages are stored under
+func LastMessage(key string) (time.Time, bool) {
+	cache.mux.RLock()
+	timeStamp, ok := cache.lastMessage[key]
+	cache.mux.RUnlock()
+	if ok {
+		return timeStamp, !timeStamp.IsZero()
+	}
+
+	timeStamp, ok = db.GetUserLastMessageTime(key)
+	cache.mux.Lock()
+	// a message may have come in while we were looking
+	if newer, seen := cache.lastMessage[key]; !seen || newer.Before(timeStamp) {
+		cache.lastMessage[key] = timeStamp
+	}
+	cache.mux.Unlock()
+	return timeStamp, ok
+}
+
+// SetLastMessage Remember a message the user just sent
+func SetLastMessage(key string, timeStamp time.Time) {
+	cache.mux.Lock()
+	if timeStamp.After(cache.lastMessage[key]) {
+		cache.lastMessage[key] = timeStamp
+	}
+	cache.mux.Unlock()
+}
@@ -0,0 +1,186 @@
+package score
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// the slow way, to check the index against
+func sorted(users map[string]Entry) (entries []Entry) {
+	for _, v := range users {
+		entries = append(entries, v)
+	}
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return
+}
+
+func checkCache(t *testing.T, c *Cache) {
+	want := sorted(c.users)
+	got := c.all()
+	if len(got) != len(want) {
+		t.Fatalf("Wanted %v entries, got %v", len(want), len(got))
+	}
+
+	total := 0
+	for i, v := range want {
+		total += v.Respec
+		if got[i] != v {
+			t.Fatalf("Entry %v: wanted %+v, got %+v", i, v, got[i])
+		}
+		if rank, ok := c.rank(v.ID); !ok || rank != i+1 {
+			t.Fatalf("%v: wanted rank %v, got %v", v.ID, i+1, rank)
+		}
+	}
+	if c.total() != total {
+		t.Errorf("Wanted total %v, got %v", total, c.total())
+	}
+}
+
+func TestCache(t *testing.T) {
+	written := make(map[string]int)
+	c := newCache(func(e Entry, respec int, isNew bool) {
+		if _, ok := written[e.ID]; ok == isNew {
+			t.Errorf("%v: wrong isNew %v", e.ID, isNew)
+		}
+		written[e.ID] += respec
+	})
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		id := fmt.Sprint(r.Intn(50))
+		c.add(id, "user"+id, r.Intn(41)-15)
+	}
+	checkCache(t, c)
+
+	for k, v := range written {
+		if c.get(k) != v {
+			t.Errorf("%v: cache has %v, database has %v", k, c.get(k), v)
+		}
+	}
+	if _, ok := c.rank("nobody"); ok {
+		t.Errorf("Ranked a user that doesn't exist")
+	}
+	if top := c.top(3); len(top) != 3 || top[0] != c.all()[0] {
+		t.Errorf("Wrong top 3 %v", top)
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	var mux sync.Mutex
+	written := make(map[string]int)
+	c := newCache(func(e Entry, respec int, isNew bool) {
+		mux.Lock()
+		written[e.ID] += respec
+		mux.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for j := 0; j < 500; j++ {
+				id := fmt.Sprint(r.Intn(20))
+				c.add(id, id, r.Intn(11)-5)
+				c.rank(id)
+				c.share(50)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	checkCache(t, c)
+	for k, v := range written {
+		if c.get(k) != v {
+			t.Errorf("%v: cache has %v, database has %v", k, c.get(k), v)
+		}
+	}
+}
+
+func TestShare(t *testing.T) {
+	c := newCache(nil)
+	for _, v := range []Entry{{"d", "", -5}, {"a", "", 40}, {"c", "", 10}, {"b", "", 35}, {"e", "", 0}} {
+		c.add(v.ID, v.ID, v.Respec)
+	}
+
+	cases := []struct {
+		percent int
+		want    int
+	}{
+		{0, 1},
+		{50, 2},
+		{100, 3},
+		{200, 5},
+	}
+	for _, v := range cases {
+		if got := c.share(v.percent); len(got) != v.want {
+			t.Errorf("%v%%: wanted %v users, got %v", v.percent, v.want, got)
+		}
+	}
+
+	// shares are the same as adding up each user's respec one at a time
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		c.add(fmt.Sprint(r.Intn(30)), "", r.Intn(30)-10)
+		total := c.total()
+		if total <= 0 {
+			continue
+		}
+		percent := r.Intn(110)
+
+		want, before := 0, 0
+		for _, v := range sorted(c.users) {
+			if before*100 > percent*total {
+				break
+			}
+			before += v.Respec
+			want++
+		}
+		if got := len(c.share(percent)); got != want {
+			t.Fatalf("%v%% of %v: wanted %v users, got %v", percent, total, want, got)
+		}
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	c := newCache(nil)
+	c.add("a", "a", 10)
+	c.add("b", "b", 5)
+
+	var toIsNew bool
+	write := func(isNew bool) { toIsNew = isNew }
+
+	if err := c.transfer("a", "b", "b", 4, 0, write); err != nil || c.get("a") != 6 || c.get("b") != 9 || toIsNew {
+		t.Errorf("Wrong transfer %v %v %v %v", err, c.get("a"), c.get("b"), toIsNew)
+	}
+	if err := c.transfer("a", "c", "c", 3, 0, write); err != nil || c.get("c") != 3 || !toIsNew {
+		t.Errorf("Wrong transfer to new user %v %v %v", err, c.get("c"), toIsNew)
+	}
+	if err := c.transfer("a", "b", "b", 2, 2, write); err != ErrBalance || c.get("a") != 3 {
+		t.Errorf("Giver went below the minimum %v %v", err, c.get("a"))
+	}
+	if err := c.transfer("nobody", "b", "b", 1, -10, write); err != ErrBalance {
+		t.Errorf("Unknown user gave respec")
+	}
+	checkCache(t, c)
+}
+
+func TestSpend(t *testing.T) {
+	c := newCache(nil)
+	c.add("a", "a", 10)
+
+	if err := c.spend("a", 4, func() bool { return true }); err != nil || c.get("a") != 6 {
+		t.Errorf("Wrong spend %v %v", err, c.get("a"))
+	}
+	if err := c.spend("a", 4, func() bool { return false }); err != ErrRefused || c.get("a") != 6 {
+		t.Errorf("Refused spend changed respec %v %v", err, c.get("a"))
+	}
+	if err := c.spend("a", 7, nil); err != ErrBalance || c.get("a") != 6 {
+		t.Errorf("Spent more than they had %v %v", err, c.get("a"))
+	}
+	checkCache(t, c)
+}
@@ -1,23 +1,68 @@
 package state
 
 import (
+	"sync"
+
 	"github.com/Jaggernaut555/respecbot/db"
 	"github.com/bwmarrin/discordgo"
 )
 
 var (
-	Session  *discordgo.Session
-	Channels map[string]bool
-	Servers  map[string]bool
+	Session *discordgo.Session
+	// commands change these while background jobs go through them, only use them with mux
+	channels map[string]bool
+	servers  map[string]bool
+	mux      sync.RWMutex
 )
 
 func init() {
-	Channels = map[string]bool{}
-	Servers = map[string]bool{}
+	channels = map[string]bool{}
+	servers = map[string]bool{}
 }
 
 func InitChannels() {
-	db.LoadActiveChannels(&Channels, &Servers)
+	mux.Lock()
+	defer mux.Unlock()
+	db.LoadActiveChannels(&channels, &servers)
+}
+
+// SetChannel Start or stop rating a channel, its server goes with it
+func SetChannel(channel *discordgo.Channel, active bool) {
+	mux.Lock()
+	defer mux.Unlock()
+	channels[channel.ID] = active
+	servers[channel.GuildID] = active
+}
+
+// IsActiveServer Check if the bot is rating anything in a guild
+func IsActiveServer(guildID string) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	return servers[guildID]
+}
+
+// ActiveServers The guilds the bot is rating
+func ActiveServers() (guildIDs []string) {
+	mux.RLock()
+	defer mux.RUnlock()
+	for k, v := range servers {
+		if v {
+			guildIDs = append(guildIDs, k)
+		}
+	}
+	return
+}
+
+// ActiveChannels The channels the bot is rating
+func ActiveChannels() (channelIDs []string) {
+	mux.RLock()
+	defer mux.RUnlock()
+	for k, v := range channels {
+		if v {
+			channelIDs = append(channelIDs, k)
+		}
+	}
+	return
 }
 
 //SendReply Send a reply to the discord session
@@ -32,5 +77,34 @@ func SendEmbed(channelID string, embed *discordgo.MessageEmbed) (msg *discordgo.
 }
 
 func IsValidChannel(channelID string) bool {
-	return Channels[channelID]
+	mux.RLock()
+	defer mux.RUnlock()
+	return channels[channelID]
+}
+
+// GuildID Get the ID of the guild a channel belongs to
+func GuildID(channelID string) (guildID string, err error) {
+	channel, err := Session.Channel(channelID)
+	if err != nil {
+		return "", err
+	}
+	return channel.GuildID, nil
+}
+
+// IsAdmin Check if a user is allowed to manage the server a channel is in
+func IsAdmin(channelID, userID string) bool {
+	perms, err := Session.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		return false
+	}
+	return perms&discordgo.PermissionAdministrator != 0 || perms&discordgo.PermissionManageServer != 0
+}
+
+// Announce Send a message to every channel the bot is active in for a guild
+func Announce(guildID string, reply string) {
+	for _, v := range ActiveChannels() {
+		if channel, err := Session.State.Channel(v); err == nil && channel.GuildID == guildID {
+			SendReply(v, reply)
+		}
+	}
 }